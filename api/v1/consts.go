@@ -1,24 +1,30 @@
 package v1
 
 const (
-	Service               ResourceNames = "Service"
-	Deployment            ResourceNames = "Deployment"
-	Secret                ResourceNames = "Secret"
-	ConfigMap             ResourceNames = "ConfigMap"
-	StatefulSet           ResourceNames = "StatefulSet"
-	Job                   ResourceNames = "Job"
-	CronJob               ResourceNames = "CronJob"
-	PersistantVolume      ResourceNames = "PersistantVolume"
-	PersistantVolumeClaim ResourceNames = "PersistantVolumeClaim"
-	ServiceAccount        ResourceNames = "ServiceAccount"
-	Role                  ResourceNames = "Role"
-	RoleBinding           ResourceNames = "RoleBinding"
-	ClusterRole           ResourceNames = "ClusterRole"
-	ClusterRoleBinding    ResourceNames = "ClusterRoleBinding"
-	NetworkPolicy         ResourceNames = "NetworkPolicy"
-	LimitRange            ResourceNames = "LimitRange"
-	ResourceQuota         ResourceNames = "ResourceQuota"
-	Namespaces            ResourceNames = "Namespaces"
+	Service                 ResourceNames = "Service"
+	Deployment              ResourceNames = "Deployment"
+	Secret                  ResourceNames = "Secret"
+	ConfigMap               ResourceNames = "ConfigMap"
+	StatefulSet             ResourceNames = "StatefulSet"
+	Job                     ResourceNames = "Job"
+	CronJob                 ResourceNames = "CronJob"
+	PersistantVolume        ResourceNames = "PersistantVolume"
+	PersistantVolumeClaim   ResourceNames = "PersistantVolumeClaim"
+	ServiceAccount          ResourceNames = "ServiceAccount"
+	Role                    ResourceNames = "Role"
+	RoleBinding             ResourceNames = "RoleBinding"
+	ClusterRole             ResourceNames = "ClusterRole"
+	ClusterRoleBinding      ResourceNames = "ClusterRoleBinding"
+	NetworkPolicy           ResourceNames = "NetworkPolicy"
+	LimitRange              ResourceNames = "LimitRange"
+	ResourceQuota           ResourceNames = "ResourceQuota"
+	Namespace               ResourceNames = "Namespace"
+	Pod                     ResourceNames = "Pod"
+	ReplicaSet              ResourceNames = "ReplicaSet"
+	HorizontalPodAutoscaler ResourceNames = "HorizontalPodAutoscaler"
+	Ingress                 ResourceNames = "Ingress"
+	PodDisruptionBudget     ResourceNames = "PodDisruptionBudget"
+	DaemonSet               ResourceNames = "DaemonSet"
 )
 
 const (
@@ -36,6 +42,54 @@ const (
 const (
 	Serve   OperationName = "SERVE"
 	CleanUp OperationName = "CLEANUP"
+	// Plan runs every handler without touching the cluster and records
+	// what would have been deleted in Status.Plan.
+	Plan OperationName = "PLAN"
+)
+
+const (
+	Deleted SweepAction = "Deleted"
+	Failed  SweepAction = "Failed"
+	Planned SweepAction = "Planned"
+	// Protected objects matched a handler but carry ProtectKey.
+	Protected SweepAction = "Protected"
+	// Marked objects are unused but still within the grace period.
+	Marked SweepAction = "Marked"
+	// Recovered objects were marked before and are in use again.
+	Recovered SweepAction = "Recovered"
+	// Flagged objects are idle but only reported, see IdleAction.
+	Flagged SweepAction = "Flagged"
+	// Suspended objects were idle and suspended instead of deleted.
+	Suspended SweepAction = "Suspended"
+)
+
+const (
+	// Report only records idle objects in the status.
+	Report IdleAction = "Report"
+	// Suspend keeps idle objects but stops them from running.
+	Suspend IdleAction = "Suspend"
+	// Delete deletes idle objects.
+	Delete IdleAction = "Delete"
+)
+
+// ProtectKey exempts an object, or every object in a namespace, from
+// sweeping when set to "true" as an annotation or a label.
+const ProtectKey = "kubeswipe.kubefit.com/protect"
+
+// CandidateSinceKey is the annotation holding the RFC3339 time an object was
+// first found unused.
+const CandidateSinceKey = "kubeswipe.kubefit.com/candidate-since"
+
+const (
+	ConditionReady     = "Ready"
+	ConditionDegraded  = "Degraded"
+	ConditionSuspended = "Suspended"
 )
 
 const SwipeDIR = "kubeswipe"
+
+// MaxRecentObjects bounds Status.RecentObjects so the status stays small.
+const MaxRecentObjects = 50
+
+// MaxPlanObjects bounds Status.Plan.
+const MaxPlanObjects = 500
@@ -32,6 +32,204 @@ type ResourceCleanerSpec struct {
 	Expire        metav1.Time     `json:"expire,omitempty"`
 	SwipePolicy   SwipePolicyName `json:"swipePolicy,omitempty"`
 	Operation     OperationName   `json:"operation"`
+	// Suspend stops scheduled runs without deleting the cleaner.
+	Suspend bool `json:"suspend,omitempty"`
+	// GracePeriod is how long an object has to stay unused after it was
+	// first found before it is deleted. Objects are marked with
+	// CandidateSinceKey when found. Zero deletes on first sight.
+	GracePeriod     *metav1.Duration  `json:"gracePeriod,omitempty"`
+	Namespaces      *NamespacePolicy  `json:"namespaces,omitempty"`
+	Volumes         *VolumePolicy     `json:"volumes,omitempty"`
+	Jobs            *JobPolicy        `json:"jobs,omitempty"`
+	CronJobs        *CronJobPolicy    `json:"cronJobs,omitempty"`
+	Workloads       *WorkloadPolicy   `json:"workloads,omitempty"`
+	ReplicaSets     *ReplicaSetPolicy `json:"replicaSets,omitempty"`
+	NetworkPolicies *IdlePolicy       `json:"networkPolicies,omitempty"`
+	Quotas          *QuotaPolicy      `json:"quotas,omitempty"`
+	Ingresses       *IdlePolicy       `json:"ingresses,omitempty"`
+	// Rules sweep objects of any kind, custom resources included, by
+	// declarative conditions.
+	Rules   []Rule        `json:"rules,omitempty"`
+	Orphans *OrphanPolicy `json:"orphans,omitempty"`
+	Pods    *PodPolicy    `json:"pods,omitempty"`
+}
+
+// PodPolicy sets what happens to pods in each kind of trouble. Classes left
+// unset keep their defaults.
+type PodPolicy struct {
+	// CrashLoopBackOff pods. Defaults to Report after 1h.
+	CrashLoopBackOff *PodClassPolicy `json:"crashLoopBackOff,omitempty"`
+	// ImagePullBackOff pods, ErrImagePull included. Defaults to Report
+	// after 1h.
+	ImagePullBackOff *PodClassPolicy `json:"imagePullBackOff,omitempty"`
+	// CreateContainerConfigError pods, which reference missing ConfigMaps or
+	// Secrets. Defaults to Report after 1h.
+	CreateContainerConfigError *PodClassPolicy `json:"createContainerConfigError,omitempty"`
+	// Evicted pods. Defaults to Delete right away.
+	Evicted *PodClassPolicy `json:"evicted,omitempty"`
+	// OOMKilled pods that are not running. Defaults to Report after 1h.
+	OOMKilled *PodClassPolicy `json:"oomKilled,omitempty"`
+	// Unschedulable pods stuck Pending. Defaults to Report after 24h.
+	Unschedulable *PodClassPolicy `json:"unschedulable,omitempty"`
+	// Idle pods, found with swipePolicy moderate once their CPU usage stayed
+	// flat over enough checks; MinAge does not apply. Defaults to Report,
+	// since Suspend and Delete act on the owner of pods with a controller.
+	Idle *PodClassPolicy `json:"idle,omitempty"`
+}
+
+// PodClassPolicy sets what happens to pods of one class.
+type PodClassPolicy struct {
+	// Action is what happens to the pods. A pod with a controller would only
+	// be recreated, so Suspend and Delete act on its top-level owner: Suspend
+	// scales Deployments and StatefulSets to zero and suspends Jobs and
+	// CronJobs, Delete deletes the owner. Pods that are bare, terminated or
+	// whose owner is out of scope are deleted themselves by Delete and only
+	// reported by Suspend.
+	Action IdleAction `json:"action,omitempty"`
+	// MinAge is how long a pod has to have been in the class first.
+	MinAge *metav1.Duration `json:"minAge,omitempty"`
+}
+
+// OrphanPolicy turns on the search for objects of any namespaced kind whose
+// owners are gone.
+type OrphanPolicy struct {
+	// Action is what happens to orphans, Report or Delete. Defaults to
+	// Report.
+	// +kubebuilder:validation:Enum=Report;Delete
+	Action IdleAction `json:"action,omitempty"`
+}
+
+// Rule sweeps the objects of a kind that meet all of its conditions.
+type Rule struct {
+	// Resource selects the objects. Its Name is the kind, such as
+	// Certificate, which the rule also reports under. The cleaner's exclude
+	// entries for the kind apply as well.
+	Resource `json:",inline"`
+	// APIVersion is the group version of the kind, such as
+	// cert-manager.io/v1.
+	APIVersion string `json:"apiVersion"`
+	// Conditions all have to hold for an object to be idle.
+	// +kubebuilder:validation:MinItems=1
+	Conditions []RuleCondition `json:"conditions"`
+	// Action is what happens to idle objects, Report or Delete. Defaults to
+	// Report.
+	// +kubebuilder:validation:Enum=Report;Delete
+	Action IdleAction `json:"action,omitempty"`
+}
+
+// RuleCondition is a test of a Rule. Every test that is set has to hold.
+type RuleCondition struct {
+	// OlderThan holds for objects created longer ago than this.
+	OlderThan *metav1.Duration `json:"olderThan,omitempty"`
+	// OwnerMissing holds for objects with owner references whose owners
+	// are all gone.
+	OwnerMissing bool `json:"ownerMissing,omitempty"`
+	// Field is a JSONPath into the object, such as {.status.phase}, that
+	// Equals and References read.
+	Field string `json:"field,omitempty"`
+	// Equals holds when Field evaluates to this value.
+	Equals *string `json:"equals,omitempty"`
+	// References holds when Field names an object of this kind that does
+	// not exist. Namespaced objects are looked up in the namespace of the
+	// object the rule tests.
+	References *ObjectKind `json:"references,omitempty"`
+}
+
+// ObjectKind identifies a kind by group version and name.
+type ObjectKind struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+// QuotaPolicy tunes how LimitRanges and ResourceQuotas are swept.
+type QuotaPolicy struct {
+	// UnusedFor is how long a namespace has to be without workloads, or a
+	// quota without anything in use, before they are deleted. Reports are
+	// made right away. Defaults to 7 days.
+	UnusedFor *metav1.Duration `json:"unusedFor,omitempty"`
+	// Action is what happens to unused LimitRanges and ResourceQuotas,
+	// Report or Delete. Defaults to Report.
+	// +kubebuilder:validation:Enum=Report;Delete
+	Action IdleAction `json:"action,omitempty"`
+}
+
+// IdlePolicy sets what happens to idle objects of kinds that are only
+// reported by default.
+type IdlePolicy struct {
+	// Action is what happens to idle objects, Report or Delete. Defaults to
+	// Report.
+	// +kubebuilder:validation:Enum=Report;Delete
+	Action IdleAction `json:"action,omitempty"`
+}
+
+// ReplicaSetPolicy tunes how old ReplicaSets are pruned.
+type ReplicaSetPolicy struct {
+	// HistoryLimit is how many old ReplicaSets of every Deployment are kept
+	// for rollbacks, whatever the Deployment's revisionHistoryLimit says.
+	// Defaults to 3.
+	HistoryLimit *int32 `json:"historyLimit,omitempty"`
+}
+
+// WorkloadPolicy tunes how Deployments and StatefulSets are swept.
+type WorkloadPolicy struct {
+	// ScaledDownFor is how long a workload has to stay scaled to zero before
+	// it is deleted, counted from the run that first found it scaled down.
+	// Defaults to 7 days.
+	ScaledDownFor *metav1.Duration `json:"scaledDownFor,omitempty"`
+}
+
+// CronJobPolicy tunes when CronJobs count as idle and what happens to them.
+type CronJobPolicy struct {
+	// SuspendedFor is how long a CronJob has to stay suspended before it is
+	// idle, counted from its last schedule. Defaults to 30 days.
+	SuspendedFor *metav1.Duration `json:"suspendedFor,omitempty"`
+	// NeverSucceededFor is how old a CronJob that never succeeded has to be
+	// before it is idle. Defaults to 7 days.
+	NeverSucceededFor *metav1.Duration `json:"neverSucceededFor,omitempty"`
+	// FailedRuns is how many of the latest runs have to have failed, all of
+	// them, for a CronJob to be idle. Only the history the CronJob keeps is
+	// seen, so CronJobs whose failedJobsHistoryLimit, 1 unless set, is lower
+	// are not judged by their failed runs. Defaults to 3.
+	FailedRuns *int32 `json:"failedRuns,omitempty"`
+	// Action is what happens to idle CronJobs. Defaults to Report.
+	Action IdleAction `json:"action,omitempty"`
+}
+
+// JobPolicy tunes how finished Jobs are swept.
+type JobPolicy struct {
+	// SucceededAfter is how long a Job is kept after it completed. Defaults
+	// to 24 hours.
+	SucceededAfter *metav1.Duration `json:"succeededAfter,omitempty"`
+	// FailedAfter is how long a Job is kept after it failed. Defaults to 7
+	// days.
+	FailedAfter *metav1.Duration `json:"failedAfter,omitempty"`
+	// KeepPerCronJob is how many of the newest finished Jobs of every
+	// CronJob are kept regardless of age. Defaults to 1.
+	KeepPerCronJob *int32 `json:"keepPerCronJob,omitempty"`
+	// Propagation is how the pods of a deleted Job are removed. Defaults to
+	// Background.
+	// +kubebuilder:validation:Enum=Background;Foreground
+	Propagation metav1.DeletionPropagation `json:"propagation,omitempty"`
+}
+
+// VolumePolicy tunes how PersistentVolumeClaims and PersistentVolumes are
+// swept. Volumes hold data, so they are only swept when an include entry
+// names PersistantVolumeClaim or PersistantVolume, and are always backed up.
+type VolumePolicy struct {
+	// UnusedFor is how long a claim has to be unmounted, stuck Pending, or a
+	// volume Released or Failed, before it is deleted. Defaults to 7 days.
+	UnusedFor *metav1.Duration `json:"unusedFor,omitempty"`
+}
+
+// NamespacePolicy tunes how namespaces are swept.
+type NamespacePolicy struct {
+	// TerminatingFor is how long a namespace has to be stuck Terminating
+	// before its finalizers are removed. Defaults to 1h.
+	TerminatingFor *metav1.Duration `json:"terminatingFor,omitempty"`
+	// EmptyFor turns on deleting Active namespaces that have held nothing
+	// but the default ServiceAccount, its token and kube-root-ca.crt for
+	// this long. The namespace and its contents are backed up first.
+	EmptyFor *metav1.Duration `json:"emptyFor,omitempty"`
 }
 
 type OperationName string
@@ -41,27 +239,87 @@ type SwipePolicyName string
 type CloudName string
 
 type ResourcesSpec struct {
-	Include []Resource `json:"include,omitempty"`
-	Exclude []Resource `json:"exclude,omitempty"`
-	Backup    bool   `json:"backup,omitempty"`
-	BackupDir string `json:"backupDir,omitempty"`
+	Include   []Resource `json:"include,omitempty"`
+	Exclude   []Resource `json:"exclude,omitempty"`
+	Backup    bool       `json:"backup,omitempty"`
+	BackupDir string     `json:"backupDir,omitempty"`
 }
 
 type Resource struct {
-	Name      string `json:"name"`
+	Name string `json:"name"`
+	// Namespace limits the entry to matching namespaces. It may be a glob
+	// such as "team-*". Empty matches every namespace.
 	Namespace string `json:"namespace,omitempty"`
+	// NamespaceSelector limits the entry to namespaces with matching labels.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Selector limits the entry to objects with matching labels.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// NameRegex limits the entry to objects whose name matches the regular
+	// expression.
+	NameRegex string `json:"nameRegex,omitempty"`
 }
 
 type ResourceNames string
 
+type SweepAction string
+
+// IdleAction is what a handler does with objects it finds idle but that may
+// still be wanted, for kinds where deleting is not the only option.
+// +kubebuilder:validation:Enum=Report;Suspend;Delete
+type IdleAction string
+
+// ResourceCount holds the per kind counters of the last run.
+type ResourceCount struct {
+	Kind      ResourceNames `json:"kind"`
+	Found     int32         `json:"found"`
+	Deleted   int32         `json:"deleted"`
+	BackedUp  int32         `json:"backedUp"`
+	Failed    int32         `json:"failed"`
+	Planned   int32         `json:"planned"`
+	Protected int32         `json:"protected"`
+	Marked    int32         `json:"marked"`
+	Recovered int32         `json:"recovered"`
+	Flagged   int32         `json:"flagged"`
+	Suspended int32         `json:"suspended"`
+}
+
+// SweptObject records what happened to a single object and why.
+type SweptObject struct {
+	Kind      ResourceNames `json:"kind"`
+	Name      string        `json:"name"`
+	Namespace string        `json:"namespace,omitempty"`
+	Action    SweepAction   `json:"action"`
+	Reason    string        `json:"reason,omitempty"`
+	Time      metav1.Time   `json:"time"`
+}
+
 // ResourceCleanerStatus defines the observed state of ResourceCleaner
 type ResourceCleanerStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	ObservedGeneration int64        `json:"observedGeneration,omitempty"`
+	LastRunTime        *metav1.Time `json:"lastRunTime,omitempty"`
+	NextRunTime        *metav1.Time `json:"nextRunTime,omitempty"`
+	// Resources holds the counters of the last run, one entry per kind.
+	Resources []ResourceCount `json:"resources,omitempty"`
+	// RecentObjects lists the most recently swept objects, newest first.
+	// It is capped at MaxRecentObjects entries.
+	RecentObjects []SweptObject `json:"recentObjects,omitempty"`
+	// Plan lists everything the last run would have deleted when the
+	// operation is not CLEANUP. It is capped at MaxPlanObjects entries.
+	Plan []SweptObject `json:"plan,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Operation",type=string,JSONPath=`.spec.operation`
+//+kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Last Run",type=date,JSONPath=`.status.lastRunTime`
+//+kubebuilder:printcolumn:name="Next Run",type=date,JSONPath=`.status.nextRunTime`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ResourceCleaner is the Schema for the resourcecleaners API
 type ResourceCleaner struct {
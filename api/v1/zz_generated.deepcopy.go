@@ -21,12 +21,263 @@ limitations under the License.
 package v1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CronJobPolicy) DeepCopyInto(out *CronJobPolicy) {
+	*out = *in
+	if in.SuspendedFor != nil {
+		in, out := &in.SuspendedFor, &out.SuspendedFor
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.NeverSucceededFor != nil {
+		in, out := &in.NeverSucceededFor, &out.NeverSucceededFor
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.FailedRuns != nil {
+		in, out := &in.FailedRuns, &out.FailedRuns
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronJobPolicy.
+func (in *CronJobPolicy) DeepCopy() *CronJobPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CronJobPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdlePolicy) DeepCopyInto(out *IdlePolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdlePolicy.
+func (in *IdlePolicy) DeepCopy() *IdlePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(IdlePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobPolicy) DeepCopyInto(out *JobPolicy) {
+	*out = *in
+	if in.SucceededAfter != nil {
+		in, out := &in.SucceededAfter, &out.SucceededAfter
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.FailedAfter != nil {
+		in, out := &in.FailedAfter, &out.FailedAfter
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.KeepPerCronJob != nil {
+		in, out := &in.KeepPerCronJob, &out.KeepPerCronJob
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobPolicy.
+func (in *JobPolicy) DeepCopy() *JobPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(JobPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacePolicy) DeepCopyInto(out *NamespacePolicy) {
+	*out = *in
+	if in.TerminatingFor != nil {
+		in, out := &in.TerminatingFor, &out.TerminatingFor
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.EmptyFor != nil {
+		in, out := &in.EmptyFor, &out.EmptyFor
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacePolicy.
+func (in *NamespacePolicy) DeepCopy() *NamespacePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectKind) DeepCopyInto(out *ObjectKind) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectKind.
+func (in *ObjectKind) DeepCopy() *ObjectKind {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectKind)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrphanPolicy) DeepCopyInto(out *OrphanPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrphanPolicy.
+func (in *OrphanPolicy) DeepCopy() *OrphanPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(OrphanPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodClassPolicy) DeepCopyInto(out *PodClassPolicy) {
+	*out = *in
+	if in.MinAge != nil {
+		in, out := &in.MinAge, &out.MinAge
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodClassPolicy.
+func (in *PodClassPolicy) DeepCopy() *PodClassPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PodClassPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodPolicy) DeepCopyInto(out *PodPolicy) {
+	*out = *in
+	if in.CrashLoopBackOff != nil {
+		in, out := &in.CrashLoopBackOff, &out.CrashLoopBackOff
+		*out = new(PodClassPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImagePullBackOff != nil {
+		in, out := &in.ImagePullBackOff, &out.ImagePullBackOff
+		*out = new(PodClassPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CreateContainerConfigError != nil {
+		in, out := &in.CreateContainerConfigError, &out.CreateContainerConfigError
+		*out = new(PodClassPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Evicted != nil {
+		in, out := &in.Evicted, &out.Evicted
+		*out = new(PodClassPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OOMKilled != nil {
+		in, out := &in.OOMKilled, &out.OOMKilled
+		*out = new(PodClassPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Unschedulable != nil {
+		in, out := &in.Unschedulable, &out.Unschedulable
+		*out = new(PodClassPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Idle != nil {
+		in, out := &in.Idle, &out.Idle
+		*out = new(PodClassPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodPolicy.
+func (in *PodPolicy) DeepCopy() *PodPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PodPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaPolicy) DeepCopyInto(out *QuotaPolicy) {
+	*out = *in
+	if in.UnusedFor != nil {
+		in, out := &in.UnusedFor, &out.UnusedFor
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaPolicy.
+func (in *QuotaPolicy) DeepCopy() *QuotaPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaSetPolicy) DeepCopyInto(out *ReplicaSetPolicy) {
+	*out = *in
+	if in.HistoryLimit != nil {
+		in, out := &in.HistoryLimit, &out.HistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaSetPolicy.
+func (in *ReplicaSetPolicy) DeepCopy() *ReplicaSetPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaSetPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Resource) DeepCopyInto(out *Resource) {
 	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Resource.
@@ -45,7 +296,7 @@ func (in *ResourceCleaner) DeepCopyInto(out *ResourceCleaner) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceCleaner.
@@ -103,6 +354,73 @@ func (in *ResourceCleanerSpec) DeepCopyInto(out *ResourceCleanerSpec) {
 	*out = *in
 	in.Resources.DeepCopyInto(&out.Resources)
 	in.Expire.DeepCopyInto(&out.Expire)
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = new(NamespacePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = new(VolumePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Jobs != nil {
+		in, out := &in.Jobs, &out.Jobs
+		*out = new(JobPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CronJobs != nil {
+		in, out := &in.CronJobs, &out.CronJobs
+		*out = new(CronJobPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Workloads != nil {
+		in, out := &in.Workloads, &out.Workloads
+		*out = new(WorkloadPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReplicaSets != nil {
+		in, out := &in.ReplicaSets, &out.ReplicaSets
+		*out = new(ReplicaSetPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NetworkPolicies != nil {
+		in, out := &in.NetworkPolicies, &out.NetworkPolicies
+		*out = new(IdlePolicy)
+		**out = **in
+	}
+	if in.Quotas != nil {
+		in, out := &in.Quotas, &out.Quotas
+		*out = new(QuotaPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Ingresses != nil {
+		in, out := &in.Ingresses, &out.Ingresses
+		*out = new(IdlePolicy)
+		**out = **in
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]Rule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Orphans != nil {
+		in, out := &in.Orphans, &out.Orphans
+		*out = new(OrphanPolicy)
+		**out = **in
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = new(PodPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceCleanerSpec.
@@ -118,6 +436,40 @@ func (in *ResourceCleanerSpec) DeepCopy() *ResourceCleanerSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceCleanerStatus) DeepCopyInto(out *ResourceCleanerStatus) {
 	*out = *in
+	if in.LastRunTime != nil {
+		in, out := &in.LastRunTime, &out.LastRunTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRunTime != nil {
+		in, out := &in.NextRunTime, &out.NextRunTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ResourceCount, len(*in))
+		copy(*out, *in)
+	}
+	if in.RecentObjects != nil {
+		in, out := &in.RecentObjects, &out.RecentObjects
+		*out = make([]SweptObject, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = make([]SweptObject, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceCleanerStatus.
@@ -130,18 +482,37 @@ func (in *ResourceCleanerStatus) DeepCopy() *ResourceCleanerStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceCount) DeepCopyInto(out *ResourceCount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceCount.
+func (in *ResourceCount) DeepCopy() *ResourceCount {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceCount)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourcesSpec) DeepCopyInto(out *ResourcesSpec) {
 	*out = *in
 	if in.Include != nil {
 		in, out := &in.Include, &out.Include
 		*out = make([]Resource, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.Exclude != nil {
 		in, out := &in.Exclude, &out.Exclude
 		*out = make([]Resource, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -154,3 +525,112 @@ func (in *ResourcesSpec) DeepCopy() *ResourcesSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Rule) DeepCopyInto(out *Rule) {
+	*out = *in
+	in.Resource.DeepCopyInto(&out.Resource)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]RuleCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Rule.
+func (in *Rule) DeepCopy() *Rule {
+	if in == nil {
+		return nil
+	}
+	out := new(Rule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuleCondition) DeepCopyInto(out *RuleCondition) {
+	*out = *in
+	if in.OlderThan != nil {
+		in, out := &in.OlderThan, &out.OlderThan
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Equals != nil {
+		in, out := &in.Equals, &out.Equals
+		*out = new(string)
+		**out = **in
+	}
+	if in.References != nil {
+		in, out := &in.References, &out.References
+		*out = new(ObjectKind)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleCondition.
+func (in *RuleCondition) DeepCopy() *RuleCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(RuleCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SweptObject) DeepCopyInto(out *SweptObject) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SweptObject.
+func (in *SweptObject) DeepCopy() *SweptObject {
+	if in == nil {
+		return nil
+	}
+	out := new(SweptObject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumePolicy) DeepCopyInto(out *VolumePolicy) {
+	*out = *in
+	if in.UnusedFor != nil {
+		in, out := &in.UnusedFor, &out.UnusedFor
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumePolicy.
+func (in *VolumePolicy) DeepCopy() *VolumePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadPolicy) DeepCopyInto(out *WorkloadPolicy) {
+	*out = *in
+	if in.ScaledDownFor != nil {
+		in, out := &in.ScaledDownFor, &out.ScaledDownFor
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadPolicy.
+func (in *WorkloadPolicy) DeepCopy() *WorkloadPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
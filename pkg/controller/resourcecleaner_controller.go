@@ -27,16 +27,21 @@ import (
 	"time"
 
 	"github.com/robfig/cron"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	kubeswipev1 "kubefit.com/kubeswipe/api/v1"
 	v1 "kubefit.com/kubeswipe/api/v1"
 	"kubefit.com/kubeswipe/pkg/utils"
 	"kubefit.com/kubeswipe/pkg/utils/services"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
 )
 
 // ResourceCleanerReconciler reconciles a ResourceCleaner object
@@ -48,6 +53,26 @@ type ResourceCleanerReconciler struct {
 //+kubebuilder:rbac:groups=kubeswipe.kubefit.com,resources=resourcecleaners,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=kubeswipe.kubefit.com,resources=resourcecleaners/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=kubeswipe.kubefit.com,resources=resourcecleaners/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;patch;delete
+//+kubebuilder:rbac:groups="",resources=namespaces/finalize,verbs=update
+//+kubebuilder:rbac:groups=*,resources=*,verbs=get;list
+//+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;patch;delete
+//+kubebuilder:rbac:groups="",resources=endpoints,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;update;patch;delete
+//+kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get;list
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;patch;delete
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;patch;delete
+//+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;patch;delete
+//+kubebuilder:rbac:groups="",resources=resourcequotas;limitranges,verbs=get;list;watch;patch;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings;clusterroles;clusterrolebindings,verbs=get;list;watch;patch;delete
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims;persistentvolumes,verbs=get;list;watch;patch;delete
+//+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses;networkpolicies,verbs=get;list;watch;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=deployments;replicasets;statefulsets,verbs=get;list;watch;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;patch;delete
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=jobs;cronjobs,verbs=get;list;watch;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -68,29 +93,82 @@ func (r *ResourceCleanerReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			logger.Info("cleaner not found")
+			return ctrl.Result{}, nil
 		}
 		logger.Error(err, "failed to get the cleaner resource")
+		return ctrl.Result{}, err
 	}
 
-	err = utils.HandleAllUnusedResources(ctx, r.Client, *cleaner)
+	if cleaner.Spec.Suspend {
+		setConditions(cleaner, nil, nil)
+		cleaner.Status.NextRunTime = nil
+		cleaner.Status.ObservedGeneration = cleaner.Generation
+		if err := r.Status().Update(ctx, cleaner); err != nil {
+			logger.Error(err, "failed to update the cleaner status")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
 
-	if err != nil {
-		logger.Error(err, "error handling unused resources")
+	rep := sweep.NewReport()
+	runErr := utils.HandleAllUnusedResources(ctx, r.Client, *cleaner, rep)
+	if runErr != nil {
+		logger.Error(runErr, "error handling unused resources")
 	}
 
 	// reconcile after some specified duration based on the schedule
+	duration := time.Second * 60
 	if cleaner.Spec.Schedule != "" {
 		schedule, err := cron.ParseStandard(cleaner.Spec.Schedule)
 		if err != nil {
 			logger.Info("Can't parse the schedule")
+		} else {
+			next := schedule.Next(time.Now())
+			duration = time.Until(next)
 		}
-
-		next := schedule.Next(time.Now())
-		duration := time.Until(next)
 		fmt.Println("duration is", duration.Seconds())
-		return ctrl.Result{RequeueAfter: duration}, nil
 	}
-	return ctrl.Result{RequeueAfter: time.Second * 60}, nil
+
+	now := metav1.Now()
+	next := metav1.NewTime(now.Add(duration))
+	rep.WriteStatus(&cleaner.Status)
+	cleaner.Status.LastRunTime = &now
+	cleaner.Status.NextRunTime = &next
+	cleaner.Status.ObservedGeneration = cleaner.Generation
+	setConditions(cleaner, rep, runErr)
+	if err := r.Status().Update(ctx, cleaner); err != nil {
+		logger.Error(err, "failed to update the cleaner status")
+	}
+
+	return ctrl.Result{RequeueAfter: duration}, nil
+}
+
+// setConditions sets the Ready, Degraded and Suspended conditions from the
+// outcome of a run. rep is nil when the run was skipped because the cleaner
+// is suspended.
+func setConditions(cleaner *v1.ResourceCleaner, rep *sweep.Report, runErr error) {
+	conditions := &cleaner.Status.Conditions
+	generation := cleaner.Generation
+
+	if rep == nil {
+		meta.SetStatusCondition(conditions, metav1.Condition{Type: v1.ConditionSuspended, Status: metav1.ConditionTrue, Reason: "Suspended", Message: "spec.suspend is set", ObservedGeneration: generation})
+		meta.SetStatusCondition(conditions, metav1.Condition{Type: v1.ConditionReady, Status: metav1.ConditionFalse, Reason: "Suspended", Message: "spec.suspend is set", ObservedGeneration: generation})
+		return
+	}
+	meta.SetStatusCondition(conditions, metav1.Condition{Type: v1.ConditionSuspended, Status: metav1.ConditionFalse, Reason: "Scheduled", ObservedGeneration: generation})
+
+	switch {
+	case runErr != nil:
+		meta.SetStatusCondition(conditions, metav1.Condition{Type: v1.ConditionReady, Status: metav1.ConditionFalse, Reason: "RunFailed", Message: runErr.Error(), ObservedGeneration: generation})
+		meta.SetStatusCondition(conditions, metav1.Condition{Type: v1.ConditionDegraded, Status: metav1.ConditionTrue, Reason: "RunFailed", Message: runErr.Error(), ObservedGeneration: generation})
+	case rep.Failed() > 0:
+		message := fmt.Sprintf("%d objects could not be handled", rep.Failed())
+		meta.SetStatusCondition(conditions, metav1.Condition{Type: v1.ConditionReady, Status: metav1.ConditionTrue, Reason: "RunCompleted", ObservedGeneration: generation})
+		meta.SetStatusCondition(conditions, metav1.Condition{Type: v1.ConditionDegraded, Status: metav1.ConditionTrue, Reason: "ObjectsFailed", Message: message, ObservedGeneration: generation})
+	default:
+		meta.SetStatusCondition(conditions, metav1.Condition{Type: v1.ConditionReady, Status: metav1.ConditionTrue, Reason: "RunCompleted", ObservedGeneration: generation})
+		meta.SetStatusCondition(conditions, metav1.Condition{Type: v1.ConditionDegraded, Status: metav1.ConditionFalse, Reason: "RunCompleted", ObservedGeneration: generation})
+	}
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -118,8 +196,10 @@ func (r *ResourceCleanerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
 
+	// Status updates after every run must not trigger another run, so only
+	// spec changes are watched; scheduled runs come from RequeueAfter.
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&kubeswipev1.ResourceCleaner{}).
+		For(&kubeswipev1.ResourceCleaner{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Complete(r)
 }
 
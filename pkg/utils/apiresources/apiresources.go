@@ -0,0 +1,66 @@
+package apiresources
+
+import (
+	"context"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+// Resource is a resource the API server can list, in its preferred version.
+type Resource struct {
+	schema.GroupVersionResource
+	Kind string
+}
+
+// Namespaced returns every namespaced resource the API server can list. When
+// some API groups cannot be discovered the resources of the others are
+// returned together with an error discovery.IsGroupDiscoveryFailedError
+// tells apart, so callers that have to see everything in a namespace can
+// fail while others go on with what was found.
+func Namespaced() ([]Resource, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(config.GetConfigOrDie())
+	if err != nil {
+		return nil, err
+	}
+	lists, discoveryErr := dc.ServerPreferredNamespacedResources()
+	if discoveryErr != nil && !discovery.IsGroupDiscoveryFailedError(discoveryErr) {
+		return nil, discoveryErr
+	}
+
+	var resources []Resource
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") || !sets.New(r.Verbs...).Has("list") {
+				continue
+			}
+			resources = append(resources, Resource{GroupVersionResource: gv.WithResource(r.Name), Kind: r.Kind})
+		}
+	}
+	return resources, discoveryErr
+}
+
+// List lists the objects of r with opts. Unstructured reads are not cached,
+// they go to the API server. Resources that turn out not to be listable
+// have no objects.
+func List(ctx context.Context, c client.Client, r Resource, opts *client.ListOptions) ([]unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(r.GroupVersion().WithKind(r.Kind + "List"))
+	if err := c.List(ctx, list, opts); err != nil {
+		if apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return list.Items, nil
+}
@@ -0,0 +1,98 @@
+package autoscalers
+
+import (
+	"context"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func HandleAllOrphanedAutoscalers(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	namespaces, err := scope.For(cleaner, v1.HorizontalPodAutoscaler).Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		if scope.SystemNamespace(ns.Name) {
+			continue
+		}
+		if err := handleOrphanedAutoscalersInNamespace(ctx, c, ns, cleaner, rep); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleOrphanedAutoscalersInNamespace(ctx context.Context, c client.Client, ns corev1.Namespace, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	s := scope.For(cleaner, v1.HorizontalPodAutoscaler)
+
+	hpas := &autoscalingv2.HorizontalPodAutoscalerList{}
+	if err := c.List(ctx, hpas, s.ListOptions(ns.Name)); err != nil {
+		return err
+	}
+	for _, hpa := range hpas.Items {
+		hpa := hpa
+		if !s.Contains(&ns, &hpa) {
+			continue
+		}
+
+		reason, err := missingTargetReason(ctx, c, hpa)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		if reason == "" {
+			err = sweep.Recover(ctx, c, cleaner, rep, v1.HorizontalPodAutoscaler, &hpa)
+		} else {
+			err = sweep.Delete(ctx, c, cleaner, rep, v1.HorizontalPodAutoscaler, &hpa, reason)
+		}
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+// missingTargetReason returns why the scale target of hpa is gone, or "" if
+// it exists. Targets of a kind the API server no longer serves are gone too.
+func missingTargetReason(ctx context.Context, c client.Client, hpa autoscalingv2.HorizontalPodAutoscaler) (string, error) {
+	ref := hpa.Spec.ScaleTargetRef
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return "", err
+	}
+
+	// unstructured reads go to the API server, the target may be of any kind
+	target := &unstructured.Unstructured{}
+	target.SetGroupVersionKind(gv.WithKind(ref.Kind))
+	err = c.Get(ctx, client.ObjectKey{Namespace: hpa.Namespace, Name: ref.Name}, target)
+	switch {
+	case err == nil:
+		return "", nil
+	case meta.IsNoMatchError(err):
+		return "scale target kind " + ref.Kind + " in " + ref.APIVersion + " is not served", nil
+	case apierrors.IsNotFound(err):
+		return "scale target " + ref.Kind + " " + ref.Name + " does not exist", nil
+	}
+	return "", err
+}
@@ -0,0 +1,57 @@
+package autoscalers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHandleAllOrphanedAutoscalers(t *testing.T) {
+	hpa := func(namespace, name, target string) client.Object {
+		return &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: target},
+			},
+		}
+	}
+	c := fake.NewClientBuilder().WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "dev"}},
+		hpa("dev", "web", "web"),
+		hpa("dev", "old", "gone"),
+		hpa("kube-system", "old", "gone"),
+	).Build()
+
+	cleaner := v1.ResourceCleaner{}
+	cleaner.Spec.Operation = v1.CleanUp
+	if err := HandleAllOrphanedAutoscalers(context.Background(), c, cleaner, sweep.NewReport()); err != nil {
+		t.Fatalf("HandleAllOrphanedAutoscalers() error = %v", err)
+	}
+
+	tests := []struct {
+		namespace   string
+		name        string
+		wantDeleted bool
+	}{
+		{namespace: "dev", name: "web"},
+		{namespace: "dev", name: "old", wantDeleted: true},
+		{namespace: "kube-system", name: "old"},
+	}
+	for _, tt := range tests {
+		err := c.Get(context.Background(), client.ObjectKey{Namespace: tt.namespace, Name: tt.name}, &autoscalingv2.HorizontalPodAutoscaler{})
+		if deleted := apierrors.IsNotFound(err); deleted != tt.wantDeleted {
+			t.Errorf("%s/%s deleted = %v, want %v (%v)", tt.namespace, tt.name, deleted, tt.wantDeleted, err)
+		}
+	}
+}
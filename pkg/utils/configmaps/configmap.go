@@ -0,0 +1,94 @@
+package configmaps
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/podspecs"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// systemConfigMaps are published into namespaces by Kubernetes and common
+// add-ons and read without being mounted, so they are never swept.
+var systemConfigMaps = map[string]bool{
+	"kube-root-ca.crt":   true,
+	"istio-ca-root-cert": true,
+}
+
+func HandleAllUnusedConfigMaps(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	namespaces, err := scope.For(cleaner, v1.ConfigMap).Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		if scope.SystemNamespace(ns.Name) {
+			continue
+		}
+		if err := handleUnusedConfigMapsInNamespace(ctx, c, ns, cleaner, rep); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleUnusedConfigMapsInNamespace(ctx context.Context, c client.Client, ns corev1.Namespace, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	s := scope.For(cleaner, v1.ConfigMap)
+
+	specs, err := podspecs.InNamespace(ctx, c, ns.Name)
+	if err != nil {
+		return err
+	}
+	referenced := make(map[string]bool)
+	for _, spec := range specs {
+		for _, name := range podspecs.ConfigMaps(spec) {
+			referenced[name] = true
+		}
+	}
+
+	configMaps := &corev1.ConfigMapList{}
+	if err := c.List(ctx, configMaps, s.ListOptions(ns.Name)); err != nil {
+		return err
+	}
+	for _, cm := range configMaps.Items {
+		cm := cm
+		if !s.Contains(&ns, &cm) || systemConfigMaps[cm.Name] {
+			continue
+		}
+		// owned config maps are managed, and cleaned up, by their owner
+		if len(cm.OwnerReferences) > 0 || referenced[cm.Name] {
+			if err := sweep.Recover(ctx, c, cleaner, rep, v1.ConfigMap, &cm); err != nil {
+				errors = append(errors, err)
+			}
+			continue
+		}
+
+		// config maps may be read through the API or from other namespaces,
+		// which no reference shows, so they are only deleted when ConfigMap
+		// is included explicitly
+		reason := "config map is not referenced by any pod or pod template"
+		if s.Listed() {
+			err = sweep.Delete(ctx, c, cleaner, rep, v1.ConfigMap, &cm, reason)
+		} else {
+			err = sweep.Flag(ctx, c, cleaner, rep, v1.ConfigMap, &cm, reason)
+		}
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
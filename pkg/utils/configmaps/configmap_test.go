@@ -0,0 +1,83 @@
+package configmaps
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHandleUnusedConfigMapsInNamespace(t *testing.T) {
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}}
+	configMap := func(name string, owners ...metav1.OwnerReference) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "dev", OwnerReferences: owners}}
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "dev"},
+		Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+			Name:         "config",
+			VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "mounted"}}},
+		}}},
+	}
+
+	tests := []struct {
+		name    string
+		include []v1.Resource
+		want    map[string]v1.SweepAction
+	}{
+		{
+			name: "only reported unless listed",
+			want: map[string]v1.SweepAction{"unused": v1.Flagged},
+		},
+		{
+			name:    "deleted when listed",
+			include: []v1.Resource{{Name: string(v1.ConfigMap)}},
+			want:    map[string]v1.SweepAction{"unused": v1.Deleted},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithObjects(
+				&ns, pod,
+				configMap("unused"),
+				configMap("mounted"),
+				configMap("kube-root-ca.crt"),
+				configMap("owned", metav1.OwnerReference{APIVersion: "v1", Kind: "Service", Name: "web", UID: "1"}),
+			).Build()
+			cleaner := v1.ResourceCleaner{}
+			cleaner.Spec.Operation = v1.CleanUp
+			cleaner.Spec.Resources.Include = tt.include
+			rep := sweep.NewReport()
+
+			if err := handleUnusedConfigMapsInNamespace(context.Background(), c, ns, cleaner, rep); err != nil {
+				t.Fatalf("handleUnusedConfigMapsInNamespace() error = %v", err)
+			}
+
+			status := v1.ResourceCleanerStatus{}
+			rep.WriteStatus(&status)
+			got := make(map[string]v1.SweepAction)
+			for _, o := range status.RecentObjects {
+				got[o.Name] = o.Action
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("recorded %v, want %v", got, tt.want)
+			}
+			for name, action := range tt.want {
+				if got[name] != action {
+					t.Errorf("%s recorded as %q, want %q", name, got[name], action)
+				}
+			}
+
+			exists := c.Get(context.Background(), client.ObjectKey{Namespace: "dev", Name: "unused"}, &corev1.ConfigMap{}) == nil
+			if wantExists := tt.want["unused"] != v1.Deleted; exists != wantExists {
+				t.Errorf("unused exists = %v, want %v", exists, wantExists)
+			}
+		})
+	}
+}
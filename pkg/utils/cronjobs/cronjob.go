@@ -0,0 +1,229 @@
+package cronjobs
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/jobs"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultSuspendedFor      = 30 * 24 * time.Hour
+	defaultNeverSucceededFor = 7 * 24 * time.Hour
+	defaultFailedRuns        = 3
+	// defaultFailedJobsHistoryLimit is the failedJobsHistoryLimit Kubernetes
+	// sets on CronJobs that leave it out.
+	defaultFailedJobsHistoryLimit = 1
+)
+
+// policy is the cleaner's CronJobPolicy with the defaults filled in.
+type policy struct {
+	suspendedFor      time.Duration
+	neverSucceededFor time.Duration
+	failedRuns        int
+	action            v1.IdleAction
+}
+
+func policyFor(cleaner v1.ResourceCleaner) policy {
+	p := policy{
+		suspendedFor:      defaultSuspendedFor,
+		neverSucceededFor: defaultNeverSucceededFor,
+		failedRuns:        defaultFailedRuns,
+		action:            v1.Report,
+	}
+	cp := cleaner.Spec.CronJobs
+	if cp == nil {
+		return p
+	}
+	if cp.SuspendedFor != nil {
+		p.suspendedFor = cp.SuspendedFor.Duration
+	}
+	if cp.NeverSucceededFor != nil {
+		p.neverSucceededFor = cp.NeverSucceededFor.Duration
+	}
+	if cp.FailedRuns != nil {
+		p.failedRuns = int(*cp.FailedRuns)
+	}
+	if cp.Action != "" {
+		p.action = cp.Action
+	}
+	return p
+}
+
+func HandleAllIdleCronJobs(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	namespaces, err := scope.For(cleaner, v1.CronJob).Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		if err := handleIdleCronJobsInNamespace(ctx, c, ns, cleaner, rep); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleIdleCronJobsInNamespace(ctx context.Context, c client.Client, ns corev1.Namespace, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	s := scope.For(cleaner, v1.CronJob)
+	p := policyFor(cleaner)
+
+	runs, err := runsByCronJob(ctx, c, ns.Name)
+	if err != nil {
+		return err
+	}
+
+	cronJobs := &batchv1.CronJobList{}
+	if err := c.List(ctx, cronJobs, s.ListOptions(ns.Name)); err != nil {
+		return err
+	}
+	for _, cj := range cronJobs.Items {
+		cj := cj
+		if !s.Contains(&ns, &cj) {
+			continue
+		}
+
+		reason := idleReason(cj, runs[cj.UID], p)
+		if reason == "" {
+			if err := sweep.Recover(ctx, c, cleaner, rep, v1.CronJob, &cj); err != nil {
+				errors = append(errors, err)
+			}
+			continue
+		}
+		if err := act(ctx, c, cleaner, rep, &cj, reason, p); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+// act applies the policy's action to an idle CronJob.
+func act(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report, cj *batchv1.CronJob, reason string, p policy) error {
+	suspended := cj.Spec.Suspend != nil && *cj.Spec.Suspend
+	if p.action == v1.Delete {
+		return sweep.Delete(ctx, c, cleaner, rep, v1.CronJob, cj, reason, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	}
+
+	if p.action != v1.Suspend || suspended {
+		return sweep.Flag(ctx, c, cleaner, rep, v1.CronJob, cj, reason)
+	}
+	if due, err := sweep.Due(ctx, c, cleaner, rep, v1.CronJob, cj, reason); !due {
+		return err
+	}
+	return Suspend(ctx, c, rep, cj, reason)
+}
+
+// Suspend stops cj from scheduling new runs and records it in rep.
+func Suspend(ctx context.Context, c client.Client, rep *sweep.Report, cj *batchv1.CronJob, reason string) error {
+	patch := client.MergeFrom(cj.DeepCopy())
+	suspend := true
+	cj.Spec.Suspend = &suspend
+	if err := c.Patch(ctx, cj, patch); err != nil {
+		rep.Record(v1.CronJob, cj, v1.Failed, "suspending: "+err.Error())
+		return err
+	}
+	rep.Record(v1.CronJob, cj, v1.Suspended, reason)
+	return nil
+}
+
+// idleReason returns why cj is idle, or "" if it is not. runs are the Jobs
+// it created, newest first.
+func idleReason(cj batchv1.CronJob, runs []batchv1.Job, p policy) string {
+	if cj.Spec.Suspend != nil && *cj.Spec.Suspend {
+		// when it was suspended is not recorded, a suspended CronJob stops
+		// being scheduled so its last schedule stands in for it
+		since := cj.CreationTimestamp
+		if cj.Status.LastScheduleTime != nil {
+			since = *cj.Status.LastScheduleTime
+		}
+		if time.Since(since.Time) < p.suspendedFor {
+			return ""
+		}
+		return "cronjob is suspended and was last scheduled at " + since.UTC().Format(time.RFC3339)
+	}
+	// CronJobs that were never scheduled may just have a long schedule
+	if cj.Status.LastScheduleTime != nil && cj.Status.LastSuccessfulTime == nil &&
+		time.Since(cj.CreationTimestamp.Time) >= p.neverSucceededFor {
+		return "cronjob has not succeeded since it was created at " + cj.CreationTimestamp.UTC().Format(time.RFC3339)
+	}
+
+	// only the failed runs the CronJob keeps can be seen; when it keeps fewer
+	// than the policy asks for, its failed runs cannot tell it is idle
+	threshold := p.failedRuns
+	if threshold <= 0 || failedHistoryLimit(cj) < threshold {
+		return ""
+	}
+	// a success since the latest schedule means the CronJob works
+	last, lastSuccess := cj.Status.LastScheduleTime, cj.Status.LastSuccessfulTime
+	if last == nil || (lastSuccess != nil && !lastSuccess.Before(last)) {
+		return ""
+	}
+
+	failed := 0
+	for _, job := range runs {
+		_, succeeded, done := jobs.Finished(job)
+		if !done {
+			continue
+		}
+		if succeeded {
+			return ""
+		}
+		failed++
+		if failed == threshold {
+			return "last " + strconv.Itoa(failed) + " runs failed"
+		}
+	}
+	return ""
+}
+
+// failedHistoryLimit returns how many failed Jobs cj keeps.
+func failedHistoryLimit(cj batchv1.CronJob) int {
+	if cj.Spec.FailedJobsHistoryLimit == nil {
+		return defaultFailedJobsHistoryLimit
+	}
+	return int(*cj.Spec.FailedJobsHistoryLimit)
+}
+
+// runsByCronJob returns the Jobs in namespace by the UID of the CronJob that
+// created them, newest first.
+func runsByCronJob(ctx context.Context, c client.Client, namespace string) (map[types.UID][]batchv1.Job, error) {
+	jobList := &batchv1.JobList{}
+	if err := c.List(ctx, jobList, &client.ListOptions{Namespace: namespace}); err != nil {
+		return nil, err
+	}
+	runs := make(map[types.UID][]batchv1.Job)
+	for _, job := range jobList.Items {
+		owner := metav1.GetControllerOf(&job)
+		if owner == nil || owner.Kind != "CronJob" {
+			continue
+		}
+		runs[owner.UID] = append(runs[owner.UID], job)
+	}
+	for _, history := range runs {
+		sort.Slice(history, func(i, j int) bool {
+			return history[j].CreationTimestamp.Before(&history[i].CreationTimestamp)
+		})
+	}
+	return runs, nil
+}
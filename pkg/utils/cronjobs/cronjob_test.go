@@ -0,0 +1,130 @@
+package cronjobs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIdleReason(t *testing.T) {
+	p := policy{suspendedFor: 7 * 24 * time.Hour, neverSucceededFor: 7 * 24 * time.Hour, failedRuns: 3}
+	ago := func(d time.Duration) *metav1.Time {
+		t := metav1.NewTime(time.Now().Add(-d))
+		return &t
+	}
+	limit := func(n int32) *int32 { return &n }
+	suspend := true
+
+	cronJob := func(age time.Duration, lastSchedule, lastSuccess *metav1.Time) batchv1.CronJob {
+		return batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "nightly", CreationTimestamp: *ago(age)},
+			Status:     batchv1.CronJobStatus{LastScheduleTime: lastSchedule, LastSuccessfulTime: lastSuccess},
+		}
+	}
+	suspended := func(cj batchv1.CronJob) batchv1.CronJob {
+		cj.Spec.Suspend = &suspend
+		return cj
+	}
+	withLimit := func(cj batchv1.CronJob, n int32) batchv1.CronJob {
+		cj.Spec.FailedJobsHistoryLimit = limit(n)
+		return cj
+	}
+	run := func(condition batchv1.JobConditionType) batchv1.Job {
+		return batchv1.Job{Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{{Type: condition, Status: corev1.ConditionTrue}}}}
+	}
+	failed, succeeded, running := run(batchv1.JobFailed), run(batchv1.JobComplete), batchv1.Job{}
+
+	month := 30 * 24 * time.Hour
+	tests := []struct {
+		name string
+		cj   batchv1.CronJob
+		runs []batchv1.Job
+		want string
+	}{
+		{
+			name: "healthy",
+			cj:   cronJob(month, ago(time.Hour), ago(time.Hour)),
+			runs: []batchv1.Job{succeeded},
+		},
+		{
+			name: "never scheduled",
+			cj:   cronJob(month, nil, nil),
+		},
+		{
+			name: "suspended recently",
+			cj:   suspended(cronJob(month, ago(time.Hour), ago(time.Hour))),
+		},
+		{
+			name: "suspended long ago",
+			cj:   suspended(cronJob(month, ago(month), ago(month))),
+			want: "cronjob is suspended",
+		},
+		{
+			name: "suspended before it was ever scheduled",
+			cj:   suspended(cronJob(month, nil, nil)),
+			want: "cronjob is suspended",
+		},
+		{
+			name: "new and suspended",
+			cj:   suspended(cronJob(time.Hour, nil, nil)),
+		},
+		{
+			name: "never succeeded",
+			cj:   cronJob(month, ago(time.Hour), nil),
+			want: "cronjob has not succeeded",
+		},
+		{
+			name: "never succeeded yet",
+			cj:   cronJob(time.Hour, ago(time.Minute), nil),
+		},
+		{
+			name: "default history limit keeps too few failed runs",
+			cj:   cronJob(month, ago(time.Hour), ago(2*time.Hour)),
+			runs: []batchv1.Job{failed},
+		},
+		{
+			name: "history limit below the policy",
+			cj:   withLimit(cronJob(month, ago(time.Hour), ago(4*time.Hour)), 2),
+			runs: []batchv1.Job{failed, failed},
+		},
+		{
+			name: "failed runs up to the history limit",
+			cj:   withLimit(cronJob(month, ago(time.Hour), ago(4*time.Hour)), 5),
+			runs: []batchv1.Job{failed, running, failed, failed},
+			want: "last 3 runs failed",
+		},
+		{
+			name: "too few failed runs",
+			cj:   withLimit(cronJob(month, ago(time.Hour), ago(4*time.Hour)), 5),
+			runs: []batchv1.Job{failed, failed},
+		},
+		{
+			name: "a success between the failures",
+			cj:   withLimit(cronJob(month, ago(time.Hour), ago(4*time.Hour)), 5),
+			runs: []batchv1.Job{failed, succeeded, failed, failed},
+		},
+		{
+			name: "succeeded since the last schedule",
+			cj:   cronJob(month, ago(2*time.Hour), ago(time.Hour)),
+			runs: []batchv1.Job{failed},
+		},
+		{
+			name: "no failed history kept",
+			cj:   withLimit(cronJob(month, ago(time.Hour), ago(2*time.Hour)), 0),
+			runs: []batchv1.Job{failed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := idleReason(tt.cj, tt.runs, p)
+			if (got == "") != (tt.want == "") || !strings.HasPrefix(got, tt.want) {
+				t.Errorf("idleReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
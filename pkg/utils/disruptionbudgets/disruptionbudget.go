@@ -0,0 +1,98 @@
+package disruptionbudgets
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/podspecs"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func HandleAllUnmatchedDisruptionBudgets(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	namespaces, err := scope.For(cleaner, v1.PodDisruptionBudget).Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		if scope.SystemNamespace(ns.Name) {
+			continue
+		}
+		if err := handleUnmatchedDisruptionBudgetsInNamespace(ctx, c, ns, cleaner, rep); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleUnmatchedDisruptionBudgetsInNamespace(ctx context.Context, c client.Client, ns corev1.Namespace, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	s := scope.For(cleaner, v1.PodDisruptionBudget)
+
+	templates, err := podspecs.Templates(ctx, c, ns.Name)
+	if err != nil {
+		return err
+	}
+
+	pdbs := &policyv1.PodDisruptionBudgetList{}
+	if err := c.List(ctx, pdbs, s.ListOptions(ns.Name)); err != nil {
+		return err
+	}
+	for _, pdb := range pdbs.Items {
+		pdb := pdb
+		if !s.Contains(&ns, &pdb) || len(pdb.OwnerReferences) > 0 {
+			continue
+		}
+
+		reason, err := unmatchedReason(pdb, templates)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		if reason == "" {
+			err = sweep.Recover(ctx, c, cleaner, rep, v1.PodDisruptionBudget, &pdb)
+		} else {
+			err = sweep.Delete(ctx, c, cleaner, rep, v1.PodDisruptionBudget, &pdb, reason)
+		}
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+// unmatchedReason returns the reason pdb selects none of templates, or "" if
+// it selects any. Templates count so budgets of scaled down workloads are
+// kept for when they scale up again. A nil selector selects nothing and an
+// empty one every pod.
+func unmatchedReason(pdb policyv1.PodDisruptionBudget, templates []corev1.PodTemplateSpec) (string, error) {
+	if pdb.Spec.Selector == nil {
+		return "budget has no selector", nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range templates {
+		if selector.Matches(labels.Set(t.Labels)) {
+			return "", nil
+		}
+	}
+	return "selector " + metav1.FormatLabelSelector(pdb.Spec.Selector) + " matches no pods or pod templates", nil
+}
@@ -37,7 +37,7 @@ func CreateFile(o interface{}, name string, dirName string, cleaner v1.ResourceC
 	}
 
 	if len(errors) > 0 {
-		errorsUtil.AggregateErrors(errors)
+		return errorsUtil.AggregateErrors(errors)
 	}
 
 	return nil
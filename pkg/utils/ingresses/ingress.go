@@ -0,0 +1,135 @@
+package ingresses
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/services"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func HandleAllDeadIngresses(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	namespaces, err := scope.For(cleaner, v1.Ingress).Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		if scope.SystemNamespace(ns.Name) {
+			continue
+		}
+		if err := handleDeadIngressesInNamespace(ctx, c, ns, cleaner, rep); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleDeadIngressesInNamespace(ctx context.Context, c client.Client, ns corev1.Namespace, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	s := scope.For(cleaner, v1.Ingress)
+
+	ingresses := &networkingv1.IngressList{}
+	if err := c.List(ctx, ingresses, s.ListOptions(ns.Name)); err != nil {
+		return err
+	}
+	for _, ing := range ingresses.Items {
+		ing := ing
+		if !s.Contains(&ns, &ing) {
+			continue
+		}
+
+		backends := serviceBackends(ing)
+		dead, err := deadBackends(ctx, c, ing.Namespace, backends)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		switch {
+		case len(dead) == 0:
+			err = sweep.Recover(ctx, c, cleaner, rep, v1.Ingress, &ing)
+		case len(dead) < len(backends):
+			// a partly broken ingress still serves traffic, it is only
+			// reported and is no candidate for deletion any more
+			if err = sweep.Recover(ctx, c, cleaner, rep, v1.Ingress, &ing); err == nil {
+				err = sweep.Flag(ctx, c, cleaner, rep, v1.Ingress, &ing, "dead backends: "+strings.Join(dead, ", "))
+			}
+		case cleaner.Spec.Ingresses != nil && cleaner.Spec.Ingresses.Action == v1.Delete:
+			err = sweep.Delete(ctx, c, cleaner, rep, v1.Ingress, &ing, "every backend is dead: "+strings.Join(dead, ", "))
+		default:
+			err = sweep.Flag(ctx, c, cleaner, rep, v1.Ingress, &ing, "every backend is dead: "+strings.Join(dead, ", "))
+		}
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+// serviceBackends returns the names of the Services the default backend and
+// the rules of ing route to, each once. Resource backends point at objects
+// of other controllers and are not judged.
+func serviceBackends(ing networkingv1.Ingress) []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(backend *networkingv1.IngressBackend) {
+		if backend == nil || backend.Service == nil || seen[backend.Service.Name] {
+			return
+		}
+		seen[backend.Service.Name] = true
+		names = append(names, backend.Service.Name)
+	}
+
+	add(ing.Spec.DefaultBackend)
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			path := path
+			add(&path.Backend)
+		}
+	}
+	return names
+}
+
+// deadBackends returns the backends among names that do not exist or are
+// idle, each with the reason.
+func deadBackends(ctx context.Context, c client.Client, namespace string, names []string) ([]string, error) {
+	var dead []string
+	for _, name := range names {
+		svc := &corev1.Service{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, svc); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, err
+			}
+			dead = append(dead, name+" (service does not exist)")
+			continue
+		}
+		idle, reason, err := services.Idle(ctx, c, svc)
+		if err != nil {
+			return nil, err
+		}
+		if idle {
+			dead = append(dead, name+" ("+reason+")")
+		}
+	}
+	return dead, nil
+}
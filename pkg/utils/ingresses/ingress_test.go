@@ -0,0 +1,160 @@
+package ingresses
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func serviceBackend(name string) networkingv1.IngressBackend {
+	return networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: name}}
+}
+
+func rule(backends ...networkingv1.IngressBackend) networkingv1.IngressRule {
+	r := networkingv1.IngressRule{IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{}}}
+	for _, b := range backends {
+		r.HTTP.Paths = append(r.HTTP.Paths, networkingv1.HTTPIngressPath{Backend: b})
+	}
+	return r
+}
+
+func TestServiceBackends(t *testing.T) {
+	defaultBackend := serviceBackend("default")
+	resource := networkingv1.IngressBackend{Resource: &corev1.TypedLocalObjectReference{Kind: "StorageBucket", Name: "static"}}
+
+	tests := []struct {
+		name string
+		spec networkingv1.IngressSpec
+		want []string
+	}{
+		{
+			name: "no backends",
+		},
+		{
+			name: "default backend first",
+			spec: networkingv1.IngressSpec{
+				DefaultBackend: &defaultBackend,
+				Rules:          []networkingv1.IngressRule{rule(serviceBackend("web"))},
+			},
+			want: []string{"default", "web"},
+		},
+		{
+			name: "each service once",
+			spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{
+				rule(serviceBackend("web"), serviceBackend("api")),
+				rule(serviceBackend("web")),
+			}},
+			want: []string{"web", "api"},
+		},
+		{
+			name: "resource backends and rules without paths are skipped",
+			spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{
+				{Host: "example.com"},
+				rule(resource, serviceBackend("web")),
+			}},
+			want: []string{"web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := serviceBackends(networkingv1.Ingress{Spec: tt.spec})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("serviceBackends() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeadBackends(t *testing.T) {
+	service := func(name string, spec corev1.ServiceSpec) client.Object {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "dev"}, Spec: spec}
+	}
+	endpoints := func(name string, subsets ...corev1.EndpointSubset) client.Object {
+		return &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "dev"}, Subsets: subsets}
+	}
+	ready := corev1.EndpointSubset{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}
+	app := corev1.ServiceSpec{Selector: map[string]string{"app": "web"}}
+
+	c := fake.NewClientBuilder().WithObjects(
+		service("web", app), endpoints("web", ready),
+		service("idle", app), endpoints("idle"),
+		service("new", app),
+		service("manual", corev1.ServiceSpec{}),
+		service("external", corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "example.com"}),
+	).Build()
+
+	tests := []struct {
+		name  string
+		names []string
+		want  []string
+	}{
+		{
+			name:  "backed",
+			names: []string{"web"},
+		},
+		{
+			name:  "external names and services without a selector are not judged",
+			names: []string{"external", "manual"},
+		},
+		{
+			name:  "missing service",
+			names: []string{"web", "gone"},
+			want:  []string{"gone (service does not exist)"},
+		},
+		{
+			name:  "idle services",
+			names: []string{"idle", "new"},
+			want:  []string{"idle (service has no endpoints)", "new (service has no endpoints object)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := deadBackends(context.Background(), c, "dev", tt.names)
+			if err != nil {
+				t.Fatalf("deadBackends() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("deadBackends() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleAllDeadIngresses(t *testing.T) {
+	ingress := func(namespace string) client.Object {
+		return &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+			Spec:       networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{rule(serviceBackend("gone"))}},
+		}
+	}
+	c := fake.NewClientBuilder().WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		ingress("dev"), ingress("kube-system"),
+	).Build()
+
+	cleaner := v1.ResourceCleaner{}
+	cleaner.Spec.Operation = v1.CleanUp
+	cleaner.Spec.Ingresses = &v1.IdlePolicy{Action: v1.Delete}
+	if err := HandleAllDeadIngresses(context.Background(), c, cleaner, sweep.NewReport()); err != nil {
+		t.Fatalf("HandleAllDeadIngresses() error = %v", err)
+	}
+
+	for namespace, wantDeleted := range map[string]bool{"dev": true, "kube-system": false} {
+		err := c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "web"}, &networkingv1.Ingress{})
+		if deleted := apierrors.IsNotFound(err); deleted != wantDeleted {
+			t.Errorf("ingress in %s deleted = %v, want %v (%v)", namespace, deleted, wantDeleted, err)
+		}
+	}
+}
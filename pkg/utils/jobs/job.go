@@ -0,0 +1,203 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultSucceededAfter = 24 * time.Hour
+	defaultFailedAfter    = 7 * 24 * time.Hour
+	defaultKeepPerCronJob = 1
+)
+
+// policy is the cleaner's JobPolicy with the defaults filled in.
+type policy struct {
+	succeededAfter time.Duration
+	failedAfter    time.Duration
+	keepPerCronJob int
+	propagation    metav1.DeletionPropagation
+}
+
+func policyFor(cleaner v1.ResourceCleaner) policy {
+	p := policy{
+		succeededAfter: defaultSucceededAfter,
+		failedAfter:    defaultFailedAfter,
+		keepPerCronJob: defaultKeepPerCronJob,
+		propagation:    metav1.DeletePropagationBackground,
+	}
+	jp := cleaner.Spec.Jobs
+	if jp == nil {
+		return p
+	}
+	if jp.SucceededAfter != nil {
+		p.succeededAfter = jp.SucceededAfter.Duration
+	}
+	if jp.FailedAfter != nil {
+		p.failedAfter = jp.FailedAfter.Duration
+	}
+	if jp.KeepPerCronJob != nil {
+		p.keepPerCronJob = int(*jp.KeepPerCronJob)
+	}
+	if jp.Propagation != "" {
+		p.propagation = jp.Propagation
+	}
+	return p
+}
+
+func HandleAllFinishedJobs(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	namespaces, err := scope.For(cleaner, v1.Job).Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		if err := handleFinishedJobsInNamespace(ctx, c, ns, cleaner, rep); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleFinishedJobsInNamespace(ctx context.Context, c client.Client, ns corev1.Namespace, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	s := scope.For(cleaner, v1.Job)
+	p := policyFor(cleaner)
+
+	jobList := &batchv1.JobList{}
+	if err := c.List(ctx, jobList, s.ListOptions(ns.Name)); err != nil {
+		return err
+	}
+	kept := newestPerCronJob(jobList.Items, p.keepPerCronJob)
+
+	for _, job := range jobList.Items {
+		job := job
+		if !s.Contains(&ns, &job) {
+			continue
+		}
+
+		reason := ""
+		if !kept[job.UID] {
+			reason = expiredReason(job, p)
+		}
+		if reason == "" {
+			if err := sweep.Recover(ctx, c, cleaner, rep, v1.Job, &job); err != nil {
+				errors = append(errors, err)
+			}
+			continue
+		}
+
+		err := sweep.Delete(ctx, c, cleaner, rep, v1.Job, &job, reason, client.PropagationPolicy(p.propagation))
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+// Suspend stops the pods of job and keeps it from starting new ones, and
+// records it in rep.
+func Suspend(ctx context.Context, c client.Client, rep *sweep.Report, job *batchv1.Job, reason string) error {
+	patch := client.MergeFrom(job.DeepCopy())
+	suspend := true
+	job.Spec.Suspend = &suspend
+	if err := c.Patch(ctx, job, patch); err != nil {
+		rep.Record(v1.Job, job, v1.Failed, "suspending: "+err.Error())
+		return err
+	}
+	rep.Record(v1.Job, job, v1.Suspended, reason)
+	return nil
+}
+
+// Finished returns when job completed or failed and whether it succeeded.
+// The last result is false while the job is still running.
+func Finished(job batchv1.Job) (time.Time, bool, bool) {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			if job.Status.CompletionTime != nil {
+				return job.Status.CompletionTime.Time, true, true
+			}
+			return cond.LastTransitionTime.Time, true, true
+		case batchv1.JobFailed:
+			return cond.LastTransitionTime.Time, false, true
+		}
+	}
+	return time.Time{}, false, false
+}
+
+// expiredReason returns why job is due for deletion, or "" to keep it.
+func expiredReason(job batchv1.Job, p policy) string {
+	// Jobs with a TTL are deleted by the TTL controller once it expires
+	if job.Spec.TTLSecondsAfterFinished != nil {
+		return ""
+	}
+	// Jobs of other controllers than CronJobs are managed by them
+	if owner := metav1.GetControllerOf(&job); owner != nil && owner.Kind != "CronJob" {
+		return ""
+	}
+
+	at, succeeded, done := Finished(job)
+	if !done {
+		return ""
+	}
+	result, keep := "failed", p.failedAfter
+	if succeeded {
+		result, keep = "succeeded", p.succeededAfter
+	}
+	if time.Since(at) < keep {
+		return ""
+	}
+	return "job " + result + " at " + at.UTC().Format(time.RFC3339) + ", kept for " + keep.String()
+}
+
+// newestPerCronJob returns the UIDs of the keep newest finished Jobs of every
+// CronJob in jobs.
+func newestPerCronJob(jobs []batchv1.Job, keep int) map[types.UID]bool {
+	byCronJob := make(map[types.UID][]batchv1.Job)
+	for _, job := range jobs {
+		owner := metav1.GetControllerOf(&job)
+		if owner == nil || owner.Kind != "CronJob" {
+			continue
+		}
+		if _, _, done := Finished(job); done {
+			byCronJob[owner.UID] = append(byCronJob[owner.UID], job)
+		}
+	}
+
+	kept := make(map[types.UID]bool)
+	for _, history := range byCronJob {
+		sort.Slice(history, func(i, j int) bool {
+			ti, _, _ := Finished(history[i])
+			tj, _, _ := Finished(history[j])
+			return ti.After(tj)
+		})
+		for i := 0; i < keep && i < len(history); i++ {
+			kept[history[i].UID] = true
+		}
+	}
+	return kept
+}
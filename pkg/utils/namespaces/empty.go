@@ -0,0 +1,160 @@
+package namespaces
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"kubefit.com/kubeswipe/pkg/utils/apiresources"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	filesUtil "kubefit.com/kubeswipe/pkg/utils/files"
+	"kubefit.com/kubeswipe/pkg/utils/podspecs"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// rootCAConfigMap is published into every namespace by kube-controller-manager.
+const rootCAConfigMap = "kube-root-ca.crt"
+
+// DeleteEmptyNamespaces deletes Active namespaces that have held nothing but
+// the objects Kubernetes creates in every namespace for the namespace
+// policy's EmptyFor. Everything in such a namespace is backed up before it
+// is deleted. Nothing happens unless EmptyFor is set.
+func DeleteEmptyNamespaces(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	if cleaner.Spec.Namespaces == nil || cleaner.Spec.Namespaces.EmptyFor == nil {
+		return nil
+	}
+	var errors []error
+	s := scope.For(cleaner, v1.Namespace)
+	namespaces, err := s.Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	var resources []apiresources.Resource
+	cleaner.Spec.Resources.Backup = true
+	cleaner = sweep.WithMinGrace(cleaner, cleaner.Spec.Namespaces.EmptyFor.Duration)
+	for _, ns := range namespaces {
+		ns := ns
+		if !s.Contains(&ns, &ns) || ns.Status.Phase != corev1.NamespaceActive {
+			continue
+		}
+		if ns.Name == "default" || scope.SystemNamespace(ns.Name) {
+			continue
+		}
+
+		// pods and pod templates are the cheap and common reason a
+		// namespace is in use, only look further when there are none
+		templates, err := podspecs.Templates(ctx, c, ns.Name)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		var contents []unstructured.Unstructured
+		if len(templates) == 0 {
+			if resources == nil {
+				// a namespace can only be called empty when every
+				// API group was seen
+				if resources, err = apiresources.Namespaced(); err != nil {
+					return err
+				}
+			}
+			contents, err = nonDefaultObjects(ctx, c, ns.Name, resources)
+			if err != nil {
+				errors = append(errors, err)
+				continue
+			}
+		}
+		if len(templates) > 0 || len(contents) > 0 {
+			if err := sweep.Recover(ctx, c, cleaner, rep, v1.Namespace, &ns); err != nil {
+				errors = append(errors, err)
+			}
+			continue
+		}
+
+		reason := "namespace holds only default objects"
+		if due, err := sweep.Due(ctx, c, cleaner, rep, v1.Namespace, &ns, reason); !due {
+			if err != nil {
+				errors = append(errors, err)
+			}
+			continue
+		}
+		if err := backupContents(ctx, c, cleaner, ns.Name, resources); err != nil {
+			rep.Record(v1.Namespace, &ns, v1.Failed, "backup failed: "+err.Error())
+			errors = append(errors, err)
+			continue
+		}
+		if err := sweep.Remove(ctx, c, cleaner, rep, v1.Namespace, &ns, reason); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+// listAll lists the objects of every resource in namespace.
+func listAll(ctx context.Context, c client.Client, namespace string, resources []apiresources.Resource, each func(unstructured.Unstructured)) error {
+	for _, r := range resources {
+		objects, err := apiresources.List(ctx, c, r, &client.ListOptions{Namespace: namespace})
+		if err != nil {
+			return err
+		}
+		for _, obj := range objects {
+			each(obj)
+		}
+	}
+	return nil
+}
+
+// nonDefaultObjects returns the objects in namespace that Kubernetes did not
+// create there by itself.
+func nonDefaultObjects(ctx context.Context, c client.Client, namespace string, resources []apiresources.Resource) ([]unstructured.Unstructured, error) {
+	var objects []unstructured.Unstructured
+	err := listAll(ctx, c, namespace, resources, func(obj unstructured.Unstructured) {
+		if !defaultObject(obj) {
+			objects = append(objects, obj)
+		}
+	})
+	return objects, err
+}
+
+// defaultObject reports whether obj is created in every namespace, or is an
+// event, which does not keep a namespace in use.
+func defaultObject(obj unstructured.Unstructured) bool {
+	switch obj.GetKind() {
+	case "ServiceAccount":
+		return obj.GetName() == "default"
+	case "ConfigMap":
+		return obj.GetName() == rootCAConfigMap
+	case "Secret":
+		return obj.GetAnnotations()[corev1.ServiceAccountNameKey] == "default"
+	case "Event":
+		return true
+	}
+	return false
+}
+
+// backupContents writes every object in namespace to a directory of its own
+// under the namespace backups.
+func backupContents(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, namespace string, resources []apiresources.Resource) error {
+	var errors []error
+	dir := sweep.BackupDir(v1.Namespace) + "/" + namespace + "/"
+	err := listAll(ctx, c, namespace, resources, func(obj unstructured.Unstructured) {
+		if err := filesUtil.CreateFile(obj.Object, obj.GetName(), dir+strings.ToLower(obj.GetKind())+"s", cleaner); err != nil {
+			errors = append(errors, err)
+		}
+	})
+	if err != nil {
+		errors = append(errors, err)
+	}
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package namespaces
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubefit.com/kubeswipe/pkg/utils/apiresources"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNonDefaultObjects(t *testing.T) {
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "preview"}
+	}
+	token := func(name, account string) *corev1.Secret {
+		m := meta(name)
+		m.Annotations = map[string]string{corev1.ServiceAccountNameKey: account}
+		return &corev1.Secret{ObjectMeta: m, Type: corev1.SecretTypeServiceAccountToken}
+	}
+	resource := func(name, kind string) apiresources.Resource {
+		return apiresources.Resource{GroupVersionResource: schema.GroupVersionResource{Version: "v1", Resource: name}, Kind: kind}
+	}
+	resources := []apiresources.Resource{
+		resource("serviceaccounts", "ServiceAccount"),
+		resource("configmaps", "ConfigMap"),
+		resource("secrets", "Secret"),
+		resource("events", "Event"),
+	}
+
+	tests := []struct {
+		name    string
+		objects []client.Object
+		want    []string
+	}{
+		{
+			name: "only default objects",
+			objects: []client.Object{
+				&corev1.ServiceAccount{ObjectMeta: meta("default")},
+				&corev1.ConfigMap{ObjectMeta: meta(rootCAConfigMap)},
+				token("default-token-abc", "default"),
+				&corev1.Event{ObjectMeta: meta("web.123")},
+			},
+		},
+		{
+			name: "objects of its own",
+			objects: []client.Object{
+				&corev1.ServiceAccount{ObjectMeta: meta("default")},
+				&corev1.ServiceAccount{ObjectMeta: meta("app")},
+				&corev1.ConfigMap{ObjectMeta: meta("settings")},
+				token("app-token-abc", "app"),
+			},
+			want: []string{"ConfigMap/settings", "Secret/app-token-abc", "ServiceAccount/app"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithObjects(tt.objects...).Build()
+			objects, err := nonDefaultObjects(context.Background(), c, "preview", resources)
+			if err != nil {
+				t.Fatalf("nonDefaultObjects() error = %v", err)
+			}
+			var got []string
+			for _, obj := range objects {
+				got = append(got, obj.GetKind()+"/"+obj.GetName())
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nonDefaultObjects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
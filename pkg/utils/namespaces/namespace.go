@@ -3,57 +3,136 @@ package namespaces
 import (
 	"context"
 	"fmt"
-	"os"
-	"os/exec"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	v1 "kubefit.com/kubeswipe/api/v1"
 	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
-	filesUtil "kubefit.com/kubeswipe/pkg/utils/files"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-func ForceDeleteTerminatingNamespaces(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner) error {
+// defaultTerminatingFor is how long a namespace has to be Terminating before
+// its finalizers are removed, unless the cleaner sets it. Controllers that
+// clean up behind their finalizers get this long to finish.
+const defaultTerminatingFor = time.Hour
+
+// blockingConditions are the namespace conditions the namespace controller
+// sets while it cannot finish deleting a namespace.
+var blockingConditions = []corev1.NamespaceConditionType{
+	corev1.NamespaceDeletionDiscoveryFailure,
+	corev1.NamespaceDeletionGVParsingFailure,
+	corev1.NamespaceDeletionContentFailure,
+	corev1.NamespaceContentRemaining,
+	corev1.NamespaceFinalizersRemaining,
+}
+
+func ForceDeleteTerminatingNamespaces(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	logger := log.FromContext(ctx)
 	var errors []error
-	namespaces := &corev1.NamespaceList{}
-	if err := c.List(ctx, namespaces); err != nil {
+	s := scope.For(cleaner, v1.Namespace)
+	namespaces, err := s.Namespaces(ctx, c)
+	if err != nil {
 		return err
 	}
-	for _, ns := range namespaces.Items {
-		// Delete namespaces that are stuck in "Terminating" state
-		if ns.Status.Phase == corev1.NamespaceTerminating || ns.Name == "test-namespace" {
-			fmt.Printf("Deleting namespace %s...\n", ns.Name)
-			patchJSON := `{"metadata":{"finalizers":[]}}`
-			cmd := exec.Command("kubectl", "patch", "namespace", ns.Name, "-p", patchJSON, "--type=merge")
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
 
-			err := cmd.Run()
+	threshold := defaultTerminatingFor
+	if cleaner.Spec.Namespaces != nil && cleaner.Spec.Namespaces.TerminatingFor != nil {
+		threshold = cleaner.Spec.Namespaces.TerminatingFor.Duration
+	}
+
+	for _, ns := range namespaces {
+		ns := ns
+		if !s.Contains(&ns, &ns) {
+			continue
+		}
+		// the candidate mark of Active namespaces belongs to
+		// DeleteEmptyNamespaces
+		if ns.Status.Phase != corev1.NamespaceTerminating || ns.DeletionTimestamp == nil {
+			continue
+		}
+
+		terminatingFor := time.Since(ns.DeletionTimestamp.Time)
+		if terminatingFor < threshold {
+			continue
+		}
+
+		blocking := BlockedBy(ns)
+		logger.Info("namespace is stuck terminating", "namespace", ns.Name, "terminatingFor", terminatingFor.Round(time.Second).String(), "blockedBy", blocking)
+		reason := fmt.Sprintf("namespace stuck terminating for %s, blocked by %s", terminatingFor.Round(time.Second), blocking)
+		if due, err := sweep.Due(ctx, c, cleaner, rep, v1.Namespace, &ns, reason); !due {
 			if err != nil {
 				errors = append(errors, err)
-			} else {
-				fmt.Printf("Namespace %s patched successfully\n", ns.Name)
-			}
-			if cleaner.Spec.Resources.Backup {
-				err := filesUtil.CreateFile(ns, ns.Name, "namespaces", cleaner)
-				if err != nil {
-					errors = append(errors, err)
-				}
-			}
-			if err := c.Delete(ctx, &ns); err != nil {
-				if cleaner.Spec.Resources.Backup {
-					err := filesUtil.CreateFile(ns, ns.Name, "namespaces", cleaner)
-					if err != nil {
-						errors = append(errors, err)
-					}
-				}
-			} else {
-				fmt.Printf("Namespace %s deleted successfully\n", ns.Name)
 			}
+			continue
+		}
+
+		if err := forceFinalize(ctx, c, cleaner, rep, &ns, reason); err != nil {
+			errors = append(errors, err)
 		}
 	}
 	if len(errors) > 0 {
-		errorsUtil.AggregateErrors(errors)
+		return errorsUtil.AggregateErrors(errors)
 	}
 	return nil
 }
+
+// BlockedBy describes what keeps ns from terminating: the remaining
+// finalizers and the messages of the namespace controller's deletion
+// conditions, which name the leftover resources and failing API groups.
+func BlockedBy(ns corev1.Namespace) string {
+	var blocking []string
+	if len(ns.Spec.Finalizers) > 0 {
+		var finalizers []string
+		for _, f := range ns.Spec.Finalizers {
+			finalizers = append(finalizers, string(f))
+		}
+		blocking = append(blocking, "spec finalizers ["+strings.Join(finalizers, ", ")+"]")
+	}
+	if len(ns.Finalizers) > 0 {
+		blocking = append(blocking, "metadata finalizers ["+strings.Join(ns.Finalizers, ", ")+"]")
+	}
+	for _, conditionType := range blockingConditions {
+		for _, condition := range ns.Status.Conditions {
+			if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+				blocking = append(blocking, string(condition.Type)+": "+condition.Message)
+			}
+		}
+	}
+	if len(blocking) == 0 {
+		return "nothing reported"
+	}
+	return strings.Join(blocking, "; ")
+}
+
+// forceFinalize backs ns up and strips its finalizers through the finalize
+// subresource, letting the API server finish the deletion.
+func forceFinalize(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report, ns *corev1.Namespace, reason string) error {
+	if err := sweep.Backup(cleaner, rep, v1.Namespace, ns); err != nil {
+		rep.Record(v1.Namespace, ns, v1.Failed, "backup failed: "+err.Error())
+		return err
+	}
+
+	if len(ns.Finalizers) > 0 {
+		patch := client.MergeFrom(ns.DeepCopy())
+		ns.Finalizers = nil
+		if err := c.Patch(ctx, ns, patch); err != nil {
+			rep.Record(v1.Namespace, ns, v1.Failed, "removing metadata finalizers: "+err.Error())
+			return err
+		}
+	}
+
+	if len(ns.Spec.Finalizers) > 0 {
+		ns.Spec.Finalizers = nil
+		if err := c.SubResource("finalize").Update(ctx, ns); err != nil {
+			rep.Record(v1.Namespace, ns, v1.Failed, "finalizing: "+err.Error())
+			return err
+		}
+	}
+
+	rep.Record(v1.Namespace, ns, v1.Deleted, "finalizers removed, "+reason)
+	return nil
+}
@@ -0,0 +1,115 @@
+package namespaces
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestForceDeleteTerminatingNamespaces(t *testing.T) {
+	terminating := func(name string, since time.Duration) *corev1.Namespace {
+		deleted := metav1.NewTime(time.Now().Add(-since))
+		return &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: name, DeletionTimestamp: &deleted, Finalizers: []string{"example.com/cleanup"}},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		policy        *v1.NamespacePolicy
+		since         time.Duration
+		protected     bool
+		wantFinalized bool
+	}{
+		{
+			name:  "just started terminating",
+			since: time.Minute,
+		},
+		{
+			name:          "stuck for longer than the default",
+			since:         2 * time.Hour,
+			wantFinalized: true,
+		},
+		{
+			name:   "stuck for less than the cleaner asks",
+			policy: &v1.NamespacePolicy{TerminatingFor: &metav1.Duration{Duration: 6 * time.Hour}},
+			since:  2 * time.Hour,
+		},
+		{
+			name:          "cleaner waits less than the default",
+			policy:        &v1.NamespacePolicy{TerminatingFor: &metav1.Duration{Duration: time.Minute}},
+			since:         10 * time.Minute,
+			wantFinalized: true,
+		},
+		{
+			name:      "protected",
+			since:     2 * time.Hour,
+			protected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns := terminating("stuck", tt.since)
+			if tt.protected {
+				ns.Annotations = map[string]string{v1.ProtectKey: "true"}
+			}
+			c := fake.NewClientBuilder().WithObjects(ns).Build()
+			cleaner := v1.ResourceCleaner{}
+			cleaner.Spec.Operation = v1.CleanUp
+			cleaner.Spec.Namespaces = tt.policy
+
+			if err := ForceDeleteTerminatingNamespaces(context.Background(), c, cleaner, sweep.NewReport()); err != nil {
+				t.Fatalf("ForceDeleteTerminatingNamespaces() error = %v", err)
+			}
+
+			// the fake client removes a deleted object once its finalizers are gone
+			err := c.Get(context.Background(), client.ObjectKey{Name: "stuck"}, &corev1.Namespace{})
+			if finalized := apierrors.IsNotFound(err); finalized != tt.wantFinalized {
+				t.Errorf("finalized = %v, want %v (%v)", finalized, tt.wantFinalized, err)
+			}
+		})
+	}
+}
+
+func TestBlockedBy(t *testing.T) {
+	tests := []struct {
+		name string
+		ns   corev1.Namespace
+		want string
+	}{
+		{
+			name: "nothing",
+			want: "nothing reported",
+		},
+		{
+			name: "finalizers and conditions",
+			ns: corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Finalizers: []string{"example.com/cleanup"}},
+				Spec:       corev1.NamespaceSpec{Finalizers: []corev1.FinalizerName{corev1.FinalizerKubernetes}},
+				Status: corev1.NamespaceStatus{Conditions: []corev1.NamespaceCondition{
+					{Type: corev1.NamespaceContentRemaining, Status: corev1.ConditionTrue, Message: "pods remain"},
+					{Type: corev1.NamespaceDeletionDiscoveryFailure, Status: corev1.ConditionFalse, Message: "all good"},
+				}},
+			},
+			want: "spec finalizers [kubernetes]; metadata finalizers [example.com/cleanup]; NamespaceContentRemaining: pods remain",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BlockedBy(tt.ns); got != tt.want {
+				t.Errorf("BlockedBy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
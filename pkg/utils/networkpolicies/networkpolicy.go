@@ -0,0 +1,104 @@
+package networkpolicies
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/podspecs"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func HandleAllUnmatchedNetworkPolicies(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	namespaces, err := scope.For(cleaner, v1.NetworkPolicy).Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		if scope.SystemNamespace(ns.Name) {
+			continue
+		}
+		if err := handleUnmatchedNetworkPoliciesInNamespace(ctx, c, ns, cleaner, rep); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleUnmatchedNetworkPoliciesInNamespace(ctx context.Context, c client.Client, ns corev1.Namespace, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	s := scope.For(cleaner, v1.NetworkPolicy)
+
+	templates, err := podspecs.Templates(ctx, c, ns.Name)
+	if err != nil {
+		return err
+	}
+
+	policies := &networkingv1.NetworkPolicyList{}
+	if err := c.List(ctx, policies, s.ListOptions(ns.Name)); err != nil {
+		return err
+	}
+	for _, np := range policies.Items {
+		np := np
+		if !s.Contains(&ns, &np) {
+			continue
+		}
+
+		reason, err := unmatchedReason(np, templates)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		if reason == "" {
+			if err := sweep.Recover(ctx, c, cleaner, rep, v1.NetworkPolicy, &np); err != nil {
+				errors = append(errors, err)
+			}
+			continue
+		}
+
+		if cleaner.Spec.NetworkPolicies != nil && cleaner.Spec.NetworkPolicies.Action == v1.Delete {
+			err = sweep.Delete(ctx, c, cleaner, rep, v1.NetworkPolicy, &np, reason)
+		} else {
+			err = sweep.Flag(ctx, c, cleaner, rep, v1.NetworkPolicy, &np, reason)
+		}
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+// unmatchedReason returns the reason np selects none of templates, or "" if
+// it selects any. Policies with an empty pod selector apply to the whole
+// namespace, pods created later included, and are always kept.
+func unmatchedReason(np networkingv1.NetworkPolicy, templates []corev1.PodTemplateSpec) (string, error) {
+	if len(np.Spec.PodSelector.MatchLabels) == 0 && len(np.Spec.PodSelector.MatchExpressions) == 0 {
+		return "", nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&np.Spec.PodSelector)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range templates {
+		if selector.Matches(labels.Set(t.Labels)) {
+			return "", nil
+		}
+	}
+	return "pod selector " + metav1.FormatLabelSelector(&np.Spec.PodSelector) + " matches no pods or pod templates", nil
+}
@@ -0,0 +1,148 @@
+package orphans
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"kubefit.com/kubeswipe/pkg/utils/apiresources"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/owners"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"kubefit.com/kubeswipe/pkg/utils/volumes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// skipped are the kinds that are never owned. The handlers of other kinds
+// leave owned objects to their owners and do not check that the owners still
+// exist, so their kinds are searched too.
+var skipped = map[schema.GroupKind]bool{
+	{Kind: "Event"}:                         true,
+	{Group: "events.k8s.io", Kind: "Event"}: true,
+}
+
+// claims is the kind of PersistentVolumeClaims, which hold data and are only
+// swept like the volumes handler does.
+var claims = schema.GroupKind{Kind: "PersistentVolumeClaim"}
+
+// HandleAllOrphans walks the objects of every namespaced kind in scope and
+// sweeps the ones whose owners are all gone.
+// Nothing happens unless the cleaner has an orphan policy.
+func HandleAllOrphans(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	policy := cleaner.Spec.Orphans
+	if policy == nil {
+		return nil
+	}
+
+	resources, err := apiresources.Namespaced()
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return err
+		}
+		// one broken API service, such as a stale metrics server, must not
+		// stop the search in the groups that were found
+		log.FromContext(ctx).Info("skipping API groups that could not be discovered", "error", err.Error())
+	}
+
+	var errors []error
+	// the kinds of these objects have handlers that mark them too
+	ctx = sweep.WithMarker(ctx, "orphans")
+	lookup := owners.NewLookup(c)
+	for _, r := range resources {
+		gk := schema.GroupKind{Group: r.Group, Kind: r.Kind}
+		if skipped[gk] {
+			continue
+		}
+		kind := v1.ResourceNames(r.Kind)
+		kindCleaner := cleaner
+		if gk == claims {
+			kind = v1.PersistantVolumeClaim
+			if !scope.For(cleaner, kind).Listed() {
+				continue
+			}
+			kindCleaner = volumes.Cleaner(cleaner)
+		}
+
+		if err := handleOrphansOfKind(ctx, c, kindCleaner, rep, r, kind, policy.Action, lookup); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleOrphansOfKind(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report, r apiresources.Resource, kind v1.ResourceNames, action v1.IdleAction, lookup *owners.Lookup) error {
+	var errors []error
+	s := scope.For(cleaner, kind)
+	namespaces, err := s.Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		ns := ns
+		if scope.SystemNamespace(ns.Name) {
+			continue
+		}
+		objects, err := apiresources.List(ctx, c, r, s.ListOptions(ns.Name))
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		for _, obj := range objects {
+			obj := obj
+			if !s.Contains(&ns, &obj) {
+				continue
+			}
+
+			reason, err := orphanReason(ctx, lookup, &obj)
+			if err != nil {
+				errors = append(errors, err)
+				continue
+			}
+			switch {
+			case reason == "":
+				err = sweep.Recover(ctx, c, cleaner, rep, kind, &obj)
+			case action == v1.Delete:
+				err = sweep.Delete(ctx, c, cleaner, rep, kind, &obj, reason)
+			default:
+				err = sweep.Flag(ctx, c, cleaner, rep, kind, &obj, reason)
+			}
+			if err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+// orphanReason returns why obj was left behind, naming the owners that are
+// gone, or "" if it was not.
+func orphanReason(ctx context.Context, lookup *owners.Lookup, obj *unstructured.Unstructured) (string, error) {
+	refs := obj.GetOwnerReferences()
+	if len(refs) == 0 {
+		return "", nil
+	}
+
+	missing, err := lookup.Missing(ctx, obj)
+	if err != nil || len(missing) < len(refs) {
+		return "", err
+	}
+	var gone []string
+	for _, ref := range missing {
+		gone = append(gone, owners.Describe(ref))
+	}
+	return "owners are gone: " + strings.Join(gone, ", "), nil
+}
@@ -0,0 +1,106 @@
+package orphans
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"kubefit.com/kubeswipe/pkg/utils/apiresources"
+	"kubefit.com/kubeswipe/pkg/utils/owners"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHandleOrphansOfKind(t *testing.T) {
+	pod := func(namespace, name string, owners ...metav1.OwnerReference) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, OwnerReferences: owners}}
+	}
+	replicaSet := func(name string, uid types.UID) metav1.OwnerReference {
+		return metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: name, UID: uid}
+	}
+	pods := apiresources.Resource{GroupVersionResource: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, Kind: "Pod"}
+
+	tests := []struct {
+		name   string
+		action v1.IdleAction
+		want   map[string]v1.SweepAction
+	}{
+		{
+			name: "reported by default",
+			want: map[string]v1.SweepAction{"dev/orphan": v1.Flagged, "dev/replaced": v1.Flagged},
+		},
+		{
+			name:   "deleted",
+			action: v1.Delete,
+			want:   map[string]v1.SweepAction{"dev/orphan": v1.Deleted, "dev/replaced": v1.Deleted},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := meta.NewDefaultRESTMapper(nil)
+			mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+			mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}, meta.RESTScopeNamespace)
+			c := fake.NewClientBuilder().WithRESTMapper(mapper).WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+				&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "dev", UID: "web-1"}},
+				pod("dev", "owned", replicaSet("web", "web-1")),
+				pod("dev", "replaced", replicaSet("web", "web-0")),
+				pod("dev", "orphan", replicaSet("old", "old-1")),
+				pod("dev", "bare"),
+				pod("kube-system", "orphan", replicaSet("old", "old-1")),
+			).Build()
+
+			cleaner := v1.ResourceCleaner{}
+			cleaner.Spec.Operation = v1.CleanUp
+			rep := sweep.NewReport()
+			err := handleOrphansOfKind(sweep.WithMarker(context.Background(), "orphans"), c, cleaner, rep, pods, v1.Pod, tt.action, owners.NewLookup(c))
+			if err != nil {
+				t.Fatalf("handleOrphansOfKind() error = %v", err)
+			}
+
+			status := v1.ResourceCleanerStatus{}
+			rep.WriteStatus(&status)
+			got := make(map[string]v1.SweepAction)
+			for _, o := range status.RecentObjects {
+				got[o.Namespace+"/"+o.Name] = o.Action
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("recorded %v, want %v", got, tt.want)
+			}
+			for key, action := range tt.want {
+				if got[key] != action {
+					t.Errorf("%s recorded as %q, want %q", key, got[key], action)
+				}
+			}
+
+			err = c.Get(context.Background(), client.ObjectKey{Namespace: "kube-system", Name: "orphan"}, &corev1.Pod{})
+			if apierrors.IsNotFound(err) {
+				t.Errorf("orphan in kube-system was deleted")
+			}
+		})
+	}
+}
+
+func TestOnlyEventsSkipped(t *testing.T) {
+	for _, gk := range []schema.GroupKind{
+		{Kind: "Pod"},
+		{Kind: "ConfigMap"},
+		{Kind: "Secret"},
+		{Group: "apps", Kind: "ReplicaSet"},
+		{Group: "batch", Kind: "Job"},
+	} {
+		if skipped[gk] {
+			t.Errorf("%s is skipped, its handler does not check owners", gk)
+		}
+	}
+}
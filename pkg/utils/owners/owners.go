@@ -0,0 +1,85 @@
+package owners
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Lookup finds owners of any kind and remembers what it found, so walking
+// many objects with the same owners stays cheap. A Lookup is meant for a
+// single run.
+type Lookup struct {
+	c      client.Client
+	exists map[types.UID]bool
+}
+
+func NewLookup(c client.Client) *Lookup {
+	return &Lookup{c: c, exists: make(map[types.UID]bool)}
+}
+
+// Missing returns the owner references of obj whose owners are gone. An
+// owner is gone when nothing of its kind and name exists, when what exists
+// has another UID, or when its kind is not served anymore.
+func (l *Lookup) Missing(ctx context.Context, obj client.Object) ([]metav1.OwnerReference, error) {
+	var missing []metav1.OwnerReference
+	for _, ref := range obj.GetOwnerReferences() {
+		exists, err := l.exist(ctx, obj.GetNamespace(), ref)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			missing = append(missing, ref)
+		}
+	}
+	return missing, nil
+}
+
+func (l *Lookup) exist(ctx context.Context, namespace string, ref metav1.OwnerReference) (bool, error) {
+	if exists, ok := l.exists[ref.UID]; ok {
+		return exists, nil
+	}
+
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return false, err
+	}
+	gvk := gv.WithKind(ref.Kind)
+	mapping, err := l.c.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			l.exists[ref.UID] = false
+			return false, nil
+		}
+		return false, err
+	}
+
+	// owners of namespaced objects live in the same namespace, or are
+	// cluster scoped
+	key := client.ObjectKey{Name: ref.Name}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		key.Namespace = namespace
+	}
+	owner := &unstructured.Unstructured{}
+	owner.SetGroupVersionKind(gvk)
+	if err := l.c.Get(ctx, key, owner); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		l.exists[ref.UID] = false
+		return false, nil
+	}
+	l.exists[ref.UID] = owner.GetUID() == ref.UID
+	return l.exists[ref.UID], nil
+}
+
+// Describe renders ref as kind/name with its UID, for reasons.
+func Describe(ref metav1.OwnerReference) string {
+	return ref.Kind + "/" + ref.Name + " (uid " + string(ref.UID) + ")"
+}
@@ -0,0 +1,154 @@
+package pods
+
+import (
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "kubefit.com/kubeswipe/api/v1"
+)
+
+// The classes of pod trouble a PodPolicy sets actions for.
+const (
+	classCrashLoopBackOff           = "CrashLoopBackOff"
+	classImagePullBackOff           = "ImagePullBackOff"
+	classCreateContainerConfigError = "CreateContainerConfigError"
+	classEvicted                    = "Evicted"
+	classOOMKilled                  = "OOMKilled"
+	classUnschedulable              = "Unschedulable"
+	classIdle                       = "Idle"
+)
+
+// defaultClassPolicies apply to the classes a cleaner sets nothing for.
+var defaultClassPolicies = map[string]classPolicy{
+	classCrashLoopBackOff:           {action: v1.Report, minAge: time.Hour},
+	classImagePullBackOff:           {action: v1.Report, minAge: time.Hour},
+	classCreateContainerConfigError: {action: v1.Report, minAge: time.Hour},
+	classEvicted:                    {action: v1.Delete},
+	classOOMKilled:                  {action: v1.Report, minAge: time.Hour},
+	classUnschedulable:              {action: v1.Report, minAge: 24 * time.Hour},
+	classIdle:                       {action: v1.Report},
+}
+
+type classPolicy struct {
+	action v1.IdleAction
+	minAge time.Duration
+}
+
+// trouble is what classify found wrong with a pod.
+type trouble struct {
+	class string
+	// since is when the pod got into trouble, as close as the pod status
+	// tells.
+	since  time.Time
+	detail string
+}
+
+// policyFor returns what cleaner wants done with pods of class.
+func policyFor(cleaner v1.ResourceCleaner, class string) classPolicy {
+	p := defaultClassPolicies[class]
+	pp := cleaner.Spec.Pods
+	if pp == nil {
+		return p
+	}
+
+	var set *v1.PodClassPolicy
+	switch class {
+	case classCrashLoopBackOff:
+		set = pp.CrashLoopBackOff
+	case classImagePullBackOff:
+		set = pp.ImagePullBackOff
+	case classCreateContainerConfigError:
+		set = pp.CreateContainerConfigError
+	case classEvicted:
+		set = pp.Evicted
+	case classOOMKilled:
+		set = pp.OOMKilled
+	case classUnschedulable:
+		set = pp.Unschedulable
+	case classIdle:
+		set = pp.Idle
+	}
+	if set == nil {
+		return p
+	}
+	if set.Action != "" {
+		p.action = set.Action
+	}
+	if set.MinAge != nil {
+		p.minAge = set.MinAge.Duration
+	}
+	return p
+}
+
+// classify returns the trouble pod is in, if it is in any of the classes.
+// Pods that are only starting up or failed a readiness probe are in none.
+func classify(pod corev1.Pod) (trouble, bool) {
+	if pod.Status.Phase == corev1.PodFailed && pod.Status.Reason == "Evicted" {
+		return trouble{class: classEvicted, since: notReadySince(pod), detail: pod.Status.Message}, true
+	}
+
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+
+	// a container killed for memory usually also crash loops, OOMKilled says more
+	for _, status := range statuses {
+		if status.Ready {
+			continue
+		}
+		if oomKilled(status.State.Terminated) || oomKilled(status.LastTerminationState.Terminated) {
+			return trouble{
+				class:  classOOMKilled,
+				since:  notReadySince(pod),
+				detail: "container " + status.Name + " was killed for running out of memory, restarted " + strconv.Itoa(int(status.RestartCount)) + " times",
+			}, true
+		}
+	}
+
+	if pod.Status.Phase == corev1.PodPending {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse && condition.Reason == corev1.PodReasonUnschedulable {
+				return trouble{class: classUnschedulable, since: condition.LastTransitionTime.Time, detail: condition.Message}, true
+			}
+		}
+	}
+
+	for _, status := range statuses {
+		waiting := status.State.Waiting
+		if waiting == nil {
+			continue
+		}
+		class := ""
+		switch waiting.Reason {
+		case "CrashLoopBackOff":
+			class = classCrashLoopBackOff
+		case "ImagePullBackOff", "ErrImagePull":
+			class = classImagePullBackOff
+		case "CreateContainerConfigError":
+			class = classCreateContainerConfigError
+		default:
+			continue
+		}
+		return trouble{
+			class:  class,
+			since:  notReadySince(pod),
+			detail: "container " + status.Name + " is waiting with " + waiting.Reason + " after " + strconv.Itoa(int(status.RestartCount)) + " restarts: " + waiting.Message,
+		}, true
+	}
+	return trouble{}, false
+}
+
+func oomKilled(terminated *corev1.ContainerStateTerminated) bool {
+	return terminated != nil && terminated.Reason == "OOMKilled"
+}
+
+// notReadySince returns when pod stopped being ready, or when it was created
+// if it never was.
+func notReadySince(pod corev1.Pod) time.Time {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status != corev1.ConditionTrue && !condition.LastTransitionTime.IsZero() {
+			return condition.LastTransitionTime.Time
+		}
+	}
+	return pod.CreationTimestamp.Time
+}
@@ -0,0 +1,185 @@
+package pods
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "kubefit.com/kubeswipe/api/v1"
+)
+
+func TestClassify(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	notReady := created.Add(time.Hour)
+	unschedulable := created.Add(2 * time.Hour)
+
+	waiting := func(reason string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{Name: "app", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}}}
+	}
+	pod := func(phase corev1.PodPhase, statuses ...corev1.ContainerStatus) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", CreationTimestamp: metav1.NewTime(created)},
+			Status:     corev1.PodStatus{Phase: phase, ContainerStatuses: statuses},
+		}
+	}
+
+	evicted := pod(corev1.PodFailed)
+	evicted.Status.Reason = "Evicted"
+
+	oom := pod(corev1.PodRunning, corev1.ContainerStatus{
+		Name:                 "app",
+		State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled"}},
+	})
+
+	recovered := pod(corev1.PodRunning, corev1.ContainerStatus{
+		Name:                 "app",
+		Ready:                true,
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled"}},
+	})
+
+	pending := pod(corev1.PodPending)
+	pending.Status.Conditions = []corev1.PodCondition{{
+		Type:               corev1.PodScheduled,
+		Status:             corev1.ConditionFalse,
+		Reason:             corev1.PodReasonUnschedulable,
+		LastTransitionTime: metav1.NewTime(unschedulable),
+	}}
+
+	crashing := pod(corev1.PodRunning, waiting("CrashLoopBackOff"))
+	crashing.Status.Conditions = []corev1.PodCondition{{
+		Type:               corev1.PodReady,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.NewTime(notReady),
+	}}
+
+	initPull := pod(corev1.PodPending)
+	initPull.Status.InitContainerStatuses = []corev1.ContainerStatus{waiting("ErrImagePull")}
+
+	tests := []struct {
+		name      string
+		pod       corev1.Pod
+		wantClass string
+		wantSince time.Time
+	}{
+		{name: "running", pod: pod(corev1.PodRunning, corev1.ContainerStatus{Name: "app", Ready: true})},
+		{name: "starting", pod: pod(corev1.PodPending, waiting("ContainerCreating"))},
+		{name: "succeeded", pod: pod(corev1.PodSucceeded)},
+		{name: "evicted", pod: evicted, wantClass: classEvicted, wantSince: created},
+		{name: "oom killed wins over crash loop", pod: oom, wantClass: classOOMKilled, wantSince: created},
+		{name: "ready after an oom kill", pod: recovered},
+		{name: "unschedulable", pod: pending, wantClass: classUnschedulable, wantSince: unschedulable},
+		{name: "crash loop since not ready", pod: crashing, wantClass: classCrashLoopBackOff, wantSince: notReady},
+		{name: "image pull of an init container", pod: initPull, wantClass: classImagePullBackOff, wantSince: created},
+		{name: "missing config", pod: pod(corev1.PodPending, waiting("CreateContainerConfigError")), wantClass: classCreateContainerConfigError, wantSince: created},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := classify(tt.pod)
+			if ok != (tt.wantClass != "") {
+				t.Fatalf("classify() = %+v, %v, want class %q", got, ok, tt.wantClass)
+			}
+			if got.class != tt.wantClass {
+				t.Errorf("class = %q, want %q", got.class, tt.wantClass)
+			}
+			if !got.since.Equal(tt.wantSince) {
+				t.Errorf("since = %v, want %v", got.since, tt.wantSince)
+			}
+		})
+	}
+}
+
+func TestPolicyFor(t *testing.T) {
+	minute := &metav1.Duration{Duration: time.Minute}
+
+	tests := []struct {
+		name       string
+		pods       *v1.PodPolicy
+		class      string
+		wantAction v1.IdleAction
+		wantMinAge time.Duration
+	}{
+		{name: "default crash loop", class: classCrashLoopBackOff, wantAction: v1.Report, wantMinAge: time.Hour},
+		{name: "default evicted", class: classEvicted, wantAction: v1.Delete},
+		{name: "default unschedulable", class: classUnschedulable, wantAction: v1.Report, wantMinAge: 24 * time.Hour},
+		{name: "default idle", class: classIdle, wantAction: v1.Report},
+		{
+			name:       "other classes set",
+			pods:       &v1.PodPolicy{Evicted: &v1.PodClassPolicy{Action: v1.Report}},
+			class:      classOOMKilled,
+			wantAction: v1.Report,
+			wantMinAge: time.Hour,
+		},
+		{
+			name:       "action set",
+			pods:       &v1.PodPolicy{CrashLoopBackOff: &v1.PodClassPolicy{Action: v1.Suspend}},
+			class:      classCrashLoopBackOff,
+			wantAction: v1.Suspend,
+			wantMinAge: time.Hour,
+		},
+		{
+			name:       "min age set",
+			pods:       &v1.PodPolicy{ImagePullBackOff: &v1.PodClassPolicy{MinAge: minute}},
+			class:      classImagePullBackOff,
+			wantAction: v1.Report,
+			wantMinAge: time.Minute,
+		},
+		{
+			name:       "idle set",
+			pods:       &v1.PodPolicy{Idle: &v1.PodClassPolicy{Action: v1.Delete}},
+			class:      classIdle,
+			wantAction: v1.Delete,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleaner := v1.ResourceCleaner{}
+			cleaner.Spec.Pods = tt.pods
+			got := policyFor(cleaner, tt.class)
+			if got.action != tt.wantAction || got.minAge != tt.wantMinAge {
+				t.Errorf("policyFor() = %+v, want action %q, minAge %v", got, tt.wantAction, tt.wantMinAge)
+			}
+		})
+	}
+}
+
+func TestCleanupReason(t *testing.T) {
+	job := []metav1.OwnerReference{{Kind: "Job", Name: "run", Controller: func() *bool { b := true; return &b }()}}
+	crashing := func(age time.Duration) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", CreationTimestamp: metav1.NewTime(time.Now().Add(-age))},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "app",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			}}},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		pod        corev1.Pod
+		wantReason bool
+		wantAction v1.IdleAction
+	}{
+		{name: "running", pod: corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodRunning}}},
+		{name: "succeeded", pod: corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodSucceeded}}, wantReason: true, wantAction: v1.Delete},
+		{
+			name: "finished pod of a job",
+			pod:  corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: job}, Status: corev1.PodStatus{Phase: corev1.PodFailed}},
+		},
+		{name: "crash loop too young", pod: crashing(time.Minute)},
+		{name: "crash loop old enough", pod: crashing(2 * time.Hour), wantReason: true, wantAction: v1.Report},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, action := cleanupReason(tt.pod, v1.ResourceCleaner{})
+			if (reason != "") != tt.wantReason || action != tt.wantAction {
+				t.Errorf("cleanupReason() = %q, %q, want reason %v, action %q", reason, action, tt.wantReason, tt.wantAction)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package pods
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"kubefit.com/kubeswipe/pkg/utils/cronjobs"
+	"kubefit.com/kubeswipe/pkg/utils/jobs"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"kubefit.com/kubeswipe/pkg/utils/workloads"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// owner is the top-level controller of a pod, which kubeswipe acts on instead
+// of a pod that would only be recreated.
+type owner struct {
+	kind v1.ResourceNames
+	// obj is nil for controllers of kinds kubeswipe does not act on.
+	obj client.Object
+	ref metav1.OwnerReference
+}
+
+func (o *owner) String() string {
+	return o.ref.Kind + " " + o.ref.Name
+}
+
+// ownerOf follows ref, the controller of a pod in namespace, up to the
+// top-level controller: the Deployment of a ReplicaSet, the CronJob of a Job,
+// or the controller itself. It returns nil if ref is gone. A controller
+// that is itself controlled by a kind kubeswipe does not know is left to
+// that kind, since scaling or suspending it would be undone.
+func ownerOf(ctx context.Context, c client.Client, namespace string, ref metav1.OwnerReference) (*owner, error) {
+	o := &owner{ref: ref}
+	switch ref.Kind {
+	case "Deployment":
+		o.kind, o.obj = v1.Deployment, &appsv1.Deployment{}
+	case "ReplicaSet":
+		o.kind, o.obj = v1.ReplicaSet, &appsv1.ReplicaSet{}
+	case "StatefulSet":
+		o.kind, o.obj = v1.StatefulSet, &appsv1.StatefulSet{}
+	case "DaemonSet":
+		o.kind, o.obj = v1.DaemonSet, &appsv1.DaemonSet{}
+	case "Job":
+		o.kind, o.obj = v1.Job, &batchv1.Job{}
+	case "CronJob":
+		o.kind, o.obj = v1.CronJob, &batchv1.CronJob{}
+	default:
+		return o, nil
+	}
+
+	err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, o.obj)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// a controller of the same name was created since, the pod's one is gone
+	if o.obj.GetUID() != ref.UID {
+		return nil, nil
+	}
+
+	next := metav1.GetControllerOf(o.obj)
+	if next == nil {
+		return o, nil
+	}
+	top, err := ownerOf(ctx, c, namespace, *next)
+	if err != nil || top == nil {
+		return o, err
+	}
+	return top, nil
+}
+
+// terminated reports whether pod ran to completion or failed for good.
+func terminated(pod corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
+
+// act applies action to pod, which lives in ns and is to be cleaned up for
+// reason. Live pods with a controller would only be recreated, so their
+// top-level owner is scaled to zero, suspended or deleted instead, once per
+// pass: acted holds the UIDs of the owners already handled. Pods are deleted
+// themselves when they are bare or terminated, or when the owner's kind is
+// out of the cleaner's scope.
+func act(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report, ns *corev1.Namespace, pod *corev1.Pod, reason string, action v1.IdleAction, acted map[types.UID]bool) error {
+	if action != v1.Delete && action != v1.Suspend {
+		return sweep.Flag(ctx, c, cleaner, rep, v1.Pod, pod, reason)
+	}
+
+	var o *owner
+	if ref := metav1.GetControllerOf(pod); ref != nil && !terminated(*pod) {
+		var err error
+		o, err = ownerOf(ctx, c, pod.Namespace, *ref)
+		if err != nil {
+			rep.Record(v1.Pod, pod, v1.Failed, "finding the owner: "+err.Error())
+			return err
+		}
+	}
+
+	// an owner whose kind the cleaner leaves out is not touched, only the pod
+	if o != nil && o.obj != nil && !scope.For(cleaner, o.kind).Contains(ns, o.obj) {
+		o = nil
+	}
+
+	if o == nil {
+		if action == v1.Delete {
+			return sweep.Delete(ctx, c, cleaner, rep, v1.Pod, pod, reason)
+		}
+		return sweep.Flag(ctx, c, cleaner, rep, v1.Pod, pod, reason+"; the pod has no controller in scope to suspend")
+	}
+	if o.obj == nil {
+		return sweep.Flag(ctx, c, cleaner, rep, v1.Pod, pod, reason+"; controlled by "+o.String()+", which kubeswipe does not act on")
+	}
+	if action == v1.Suspend {
+		switch o.kind {
+		case v1.DaemonSet:
+			return sweep.Flag(ctx, c, cleaner, rep, v1.Pod, pod, reason+"; DaemonSet "+o.ref.Name+" cannot be suspended")
+		case v1.ReplicaSet:
+			// the replicasets handler deletes ReplicaSets without an owner
+			// once they are scaled to zero, suspending would delete it
+			return sweep.Flag(ctx, c, cleaner, rep, v1.Pod, pod, reason+"; ReplicaSet "+o.ref.Name+" has no Deployment and cannot be suspended")
+		}
+	}
+
+	if acted[o.obj.GetUID()] {
+		return nil
+	}
+	acted[o.obj.GetUID()] = true
+
+	switch {
+	case action == v1.Delete:
+		reason += "; deleting " + o.String()
+	case o.kind == v1.Job || o.kind == v1.CronJob:
+		reason += "; suspending " + o.String()
+	default:
+		reason += "; scaling " + o.String() + " to zero"
+	}
+
+	protected, err := sweep.Protected(ctx, c, o.obj)
+	if err != nil {
+		rep.Record(o.kind, o.obj, v1.Failed, "checking protection: "+err.Error())
+		return err
+	}
+	if protected {
+		rep.Record(o.kind, o.obj, v1.Protected, v1.ProtectKey+" is set")
+		return nil
+	}
+	// the pod carries the grace mark, the owner's handler keeps its own, and
+	// the plan names the owner a cleanup would change
+	if due, err := sweep.DueFor(ctx, c, cleaner, rep, v1.Pod, pod, o.kind, o.obj, reason); !due {
+		return err
+	}
+
+	if action == v1.Delete {
+		return sweep.Remove(ctx, c, cleaner, rep, o.kind, o.obj, reason, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	}
+	switch obj := o.obj.(type) {
+	case *batchv1.CronJob:
+		return cronjobs.Suspend(ctx, c, rep, obj, reason)
+	case *batchv1.Job:
+		return jobs.Suspend(ctx, c, rep, obj, reason)
+	}
+	return workloads.ScaleToZero(ctx, c, rep, o.kind, o.obj, reason)
+}
@@ -0,0 +1,204 @@
+package pods
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func controller(kind, name string, uid types.UID) []metav1.OwnerReference {
+	isController := true
+	return []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: kind, Name: name, UID: uid, Controller: &isController}}
+}
+
+func TestAct(t *testing.T) {
+	replicas := int32(2)
+	objects := func() []client.Object {
+		return []client.Object{
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}},
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "dev", UID: "deploy"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			},
+			&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "dev", UID: "rs", OwnerReferences: controller("Deployment", "web", "deploy")}},
+			&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "bare", Namespace: "dev", UID: "bare-rs"}},
+			&appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "dev", UID: "ds"}},
+		}
+	}
+	pod := func(name string, owners []metav1.OwnerReference) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "dev", OwnerReferences: owners},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	ofDeployment := pod("web-1-abc", controller("ReplicaSet", "web-1", "rs"))
+
+	type outcome struct {
+		kind   v1.ResourceNames
+		name   string
+		action v1.SweepAction
+	}
+	tests := []struct {
+		name      string
+		operation v1.OperationName
+		exclude   []v1.Resource
+		pod       *corev1.Pod
+		action    v1.IdleAction
+		want      outcome
+		// gone lists the objects that are deleted afterwards, by kind.
+		gone map[v1.ResourceNames]string
+	}{
+		{
+			name:   "report",
+			pod:    ofDeployment,
+			action: v1.Report,
+			want:   outcome{v1.Pod, "web-1-abc", v1.Flagged},
+		},
+		{
+			name:   "delete removes the top-level owner",
+			pod:    ofDeployment,
+			action: v1.Delete,
+			want:   outcome{v1.Deployment, "web", v1.Deleted},
+			gone:   map[v1.ResourceNames]string{v1.Deployment: "web"},
+		},
+		{
+			name:   "suspend scales the top-level owner",
+			pod:    ofDeployment,
+			action: v1.Suspend,
+			want:   outcome{v1.Deployment, "web", v1.Suspended},
+		},
+		{
+			name:      "dry run plans the owner",
+			operation: v1.Plan,
+			pod:       ofDeployment,
+			action:    v1.Delete,
+			want:      outcome{v1.Deployment, "web", v1.Planned},
+		},
+		{
+			name:    "owner kind out of scope deletes the pod",
+			exclude: []v1.Resource{{Name: string(v1.Deployment)}},
+			pod:     ofDeployment,
+			action:  v1.Delete,
+			want:    outcome{v1.Pod, "web-1-abc", v1.Deleted},
+			gone:    map[v1.ResourceNames]string{v1.Pod: "web-1-abc"},
+		},
+		{
+			name:    "owner kind out of scope is not suspended",
+			exclude: []v1.Resource{{Name: string(v1.Deployment)}},
+			pod:     ofDeployment,
+			action:  v1.Suspend,
+			want:    outcome{v1.Pod, "web-1-abc", v1.Flagged},
+		},
+		{
+			name:   "owner gone deletes the pod",
+			pod:    pod("old-abc", controller("ReplicaSet", "old", "old-rs")),
+			action: v1.Delete,
+			want:   outcome{v1.Pod, "old-abc", v1.Deleted},
+			gone:   map[v1.ResourceNames]string{v1.Pod: "old-abc"},
+		},
+		{
+			name:   "bare replica set is not suspended",
+			pod:    pod("bare-abc", controller("ReplicaSet", "bare", "bare-rs")),
+			action: v1.Suspend,
+			want:   outcome{v1.Pod, "bare-abc", v1.Flagged},
+		},
+		{
+			name:   "daemon set is not suspended",
+			pod:    pod("agent-abc", controller("DaemonSet", "agent", "ds")),
+			action: v1.Suspend,
+			want:   outcome{v1.Pod, "agent-abc", v1.Flagged},
+		},
+		{
+			name:   "bare pod",
+			pod:    pod("bare", nil),
+			action: v1.Delete,
+			want:   outcome{v1.Pod, "bare", v1.Deleted},
+			gone:   map[v1.ResourceNames]string{v1.Pod: "bare"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			pod := tt.pod.DeepCopy()
+			c := fake.NewClientBuilder().WithObjects(append(objects(), pod)...).Build()
+			cleaner := v1.ResourceCleaner{}
+			cleaner.Spec.Operation = v1.CleanUp
+			if tt.operation != "" {
+				cleaner.Spec.Operation = tt.operation
+			}
+			cleaner.Spec.Resources.Exclude = tt.exclude
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}}
+			rep := sweep.NewReport()
+
+			if err := act(ctx, c, cleaner, rep, ns, pod, "in trouble", tt.action, map[types.UID]bool{}); err != nil {
+				t.Fatalf("act() error = %v", err)
+			}
+
+			status := v1.ResourceCleanerStatus{}
+			rep.WriteStatus(&status)
+			recorded := append(status.RecentObjects, status.Plan...)
+			if len(recorded) != 1 {
+				t.Fatalf("recorded %+v, want only %+v", recorded, tt.want)
+			}
+			if got := (outcome{recorded[0].Kind, recorded[0].Name, recorded[0].Action}); got != tt.want {
+				t.Errorf("recorded %+v, want %+v", got, tt.want)
+			}
+
+			deployment := &appsv1.Deployment{}
+			err := c.Get(ctx, client.ObjectKey{Namespace: "dev", Name: "web"}, deployment)
+			if deleted := err != nil; deleted != (tt.gone[v1.Deployment] == "web") {
+				t.Errorf("deployment deleted = %v (%v)", deleted, err)
+			}
+			if err == nil {
+				scaled := *deployment.Spec.Replicas == 0
+				if wantScaled := tt.want.action == v1.Suspended; scaled != wantScaled {
+					t.Errorf("deployment scaled to zero = %v, want %v", scaled, wantScaled)
+				}
+			}
+			err = c.Get(ctx, client.ObjectKeyFromObject(pod), &corev1.Pod{})
+			if deleted := err != nil; deleted != (tt.gone[v1.Pod] == pod.Name) {
+				t.Errorf("pod deleted = %v (%v)", deleted, err)
+			}
+		})
+	}
+}
+
+func TestActOncePerOwner(t *testing.T) {
+	ctx := context.Background()
+	replicas := int32(2)
+	pods := []*corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-1-a", Namespace: "dev", OwnerReferences: controller("ReplicaSet", "web-1", "rs")}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-1-b", Namespace: "dev", OwnerReferences: controller("ReplicaSet", "web-1", "rs")}},
+	}
+	c := fake.NewClientBuilder().WithObjects(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "dev", UID: "deploy"}, Spec: appsv1.DeploymentSpec{Replicas: &replicas}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "dev", UID: "rs", OwnerReferences: controller("Deployment", "web", "deploy")}},
+		pods[0], pods[1],
+	).Build()
+	cleaner := v1.ResourceCleaner{}
+	cleaner.Spec.Operation = v1.CleanUp
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}}
+	rep := sweep.NewReport()
+
+	acted := map[types.UID]bool{}
+	for _, pod := range pods {
+		if err := act(ctx, c, cleaner, rep, ns, pod, "in trouble", v1.Suspend, acted); err != nil {
+			t.Fatalf("act() error = %v", err)
+		}
+	}
+
+	status := v1.ResourceCleanerStatus{}
+	rep.WriteStatus(&status)
+	if len(status.RecentObjects) != 1 || status.RecentObjects[0].Action != v1.Suspended {
+		t.Errorf("recorded %+v, want one suspension", status.RecentObjects)
+	}
+}
@@ -9,10 +9,12 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
 	v1 "kubefit.com/kubeswipe/api/v1"
 	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
-	filesUtil "kubefit.com/kubeswipe/pkg/utils/files"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
@@ -23,64 +25,106 @@ const (
 	annotationKey            = "last_cpu_usage_time"
 	cpuAnnotationKey         = "cpu_usage"
 	updateCountAnnotationKey = "update_count"
+	// idleMilliCPU is the CPU usage, in millicores, below which a pod counts
+	// as idle.
+	idleMilliCPU = 5
+	// cpuVariationMilli is how much, in millicores, the usage of an idle pod
+	// may change between checks before it is no longer tracked.
+	cpuVariationMilli = 3
 )
 
-func DeleteAllPendingAndFailedPods(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner) error {
+func DeleteAllPendingAndFailedPods(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
 	fmt.Println("pending and failed pods")
+	namespaces, err := scope.For(cleaner, v1.Pod).Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	var errors []error
+	for _, ns := range namespaces {
+		err := deletePendingAndFailedPodsInNamespace(ctx, c, ns, cleaner, rep)
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+
+	return nil
+}
+
+func deletePendingAndFailedPodsInNamespace(ctx context.Context, c client.Client, ns corev1.Namespace, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	s := scope.For(cleaner, v1.Pod)
 	pods := &corev1.PodList{}
-	if err := c.List(ctx, pods); err != nil {
+	if err := c.List(ctx, pods, s.ListOptions(ns.Name)); err != nil {
 		return err
 	}
 
 	var errors []error
+	acted := map[types.UID]bool{}
 	for _, pod := range pods.Items {
-		fmt.Println("pod status phase", pod.Status.Phase)
-		switch pod.Status.Phase {
-		case corev1.PodFailed, corev1.PodSucceeded: // Add PodSucceeded case since we don't want to keep successful pods
-			if cleaner.Spec.Resources.Backup {
-				err := filesUtil.CreateFile(pod, "pods", pod.Name, cleaner)
-				if err != nil {
-					errors = append(errors, err)
-				}
+		pod := pod
+		if !s.Contains(&ns, &pod) {
+			continue
+		}
+		reason, action := cleanupReason(pod, cleaner)
+		if reason == "" {
+			// pods DeleteAllUnusedPods is tracking keep their mark, that
+			// handler decides when they are in use again
+			if _, tracked := pod.Annotations[annotationKey]; tracked {
+				continue
 			}
-
-			err := c.Delete(ctx, &pod)
-			if err != nil {
+			if err := sweep.Recover(ctx, c, cleaner, rep, v1.Pod, &pod); err != nil {
 				errors = append(errors, err)
 			}
-		case corev1.PodPending:
-			continue // Skip pending pods
-
+			continue
 		}
 
-		for _, status := range pod.Status.ContainerStatuses {
-			if !status.Ready {
-				if cleaner.Spec.Resources.Backup {
-					err := filesUtil.CreateFile(pod, "pods", pod.Name, cleaner)
-					if err != nil {
-						errors = append(errors, err)
-					}
-				}
-				err := c.Delete(ctx, &pod)
-				if err != nil {
-					errors = append(errors, err)
-				}
-				fmt.Println("deleted the pod", pod.Name)
-				continue
-			}
+		if err := act(ctx, c, cleaner, rep, &ns, &pod, reason, action, acted); err != nil {
+			errors = append(errors, err)
 		}
-
 	}
 
 	if len(errors) > 0 {
-		errorsUtil.AggregateErrors(errors)
+		return errorsUtil.AggregateErrors(errors)
 	}
 
 	return nil
 }
 
-func DeleteAllUnusedPods(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner) error {
-	namespaces := &corev1.NamespaceList{}
+// cleanupReason returns why pod should be cleaned up and what to do with it,
+// or "" to keep it. Pods in trouble are acted on as the cleaner's pod policy
+// says once they have been in trouble for its minimum age; other pods only
+// once they terminated.
+func cleanupReason(pod corev1.Pod, cleaner v1.ResourceCleaner) (string, v1.IdleAction) {
+	// finished pods of a Job are its history, the jobs handler removes them
+	// with it
+	if owner := metav1.GetControllerOf(&pod); owner != nil && owner.Kind == "Job" && terminated(pod) {
+		return "", ""
+	}
+
+	if t, ok := classify(pod); ok {
+		p := policyFor(cleaner, t.class)
+		if time.Since(t.since) < p.minAge {
+			return "", ""
+		}
+		reason := t.class + " since " + t.since.UTC().Format(time.RFC3339)
+		if t.detail != "" {
+			reason += ": " + t.detail
+		}
+		return reason, p.action
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodFailed, corev1.PodSucceeded:
+		return "pod phase is " + string(pod.Status.Phase), v1.Delete
+	}
+	return "", ""
+}
+
+func DeleteAllUnusedPods(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
 	var errors []error
 	config := config.GetConfigOrDie()
 	mc, err := metrics.NewForConfig(config)
@@ -88,12 +132,16 @@ func DeleteAllUnusedPods(ctx context.Context, c client.Client, cleaner v1.Resour
 		panic(err)
 	}
 
-	err = c.List(ctx, namespaces)
+	s := scope.For(cleaner, v1.Pod)
+	namespaces, err := s.Namespaces(ctx, c)
 	if err != nil {
 		return err
 	}
 
-	for _, ns := range namespaces.Items {
+	action := policyFor(cleaner, classIdle).action
+	for _, ns := range namespaces {
+		ns := ns
+		acted := map[types.UID]bool{}
 		podMetrics, err := mc.MetricsV1beta1().PodMetricses(ns.Name).List(ctx, metav1.ListOptions{})
 		if err != nil {
 			fmt.Println("Error fetching the metrics:", err)
@@ -103,13 +151,13 @@ func DeleteAllUnusedPods(ctx context.Context, c client.Client, cleaner v1.Resour
 		for _, po := range podMetrics.Items {
 			podContainers := po.Containers
 
+			// usage is mostly fractions of a core, so it is summed in millicores
 			totalCpu := 0
 			for _, container := range podContainers {
-				cpuQuantity, _ := container.Usage.Cpu().AsInt64()
-				totalCpu += int(cpuQuantity)
+				totalCpu += int(container.Usage.Cpu().MilliValue())
 			}
 
-			if totalCpu == 0 {
+			if totalCpu < idleMilliCPU {
 
 				pod := &corev1.Pod{}
 				err := c.Get(ctx, client.ObjectKey{Name: po.Name, Namespace: ns.Name}, pod)
@@ -117,6 +165,9 @@ func DeleteAllUnusedPods(ctx context.Context, c client.Client, cleaner v1.Resour
 					fmt.Printf("Error getting pod %s: %v\n", po.Name, err)
 					continue
 				}
+				if !s.Contains(&ns, pod) {
+					continue
+				}
 
 				annotations := pod.GetAnnotations()
 				if annotations == nil {
@@ -130,9 +181,12 @@ func DeleteAllUnusedPods(ctx context.Context, c client.Client, cleaner v1.Resour
 					annotations[cpuAnnotationKey] = fmt.Sprintf("%d", totalCpu)
 					annotations[updateCountAnnotationKey] = "1"
 					pod.SetAnnotations(annotations)
-					err = c.Update(ctx, pod)
-					if err != nil {
-						return nil
+					// a dry run must not touch the cluster, so idle tracking is not persisted
+					if !sweep.DryRun(cleaner) {
+						err = c.Update(ctx, pod)
+						if err != nil {
+							return nil
+						}
 					}
 				}
 
@@ -158,14 +212,16 @@ func DeleteAllUnusedPods(ctx context.Context, c client.Client, cleaner v1.Resour
 					cpuDifference := int64(math.Abs(float64(int64(totalCpu) - int64(lastCPUUsage))))
 
 					// If CPU usage is the same or increased, update the annotation
-					if cpuDifference < 3 {
+					if cpuDifference < cpuVariationMilli {
 						annotations[annotationKey] = time.Now().Format(time.RFC3339)
 						annotations[cpuAnnotationKey] = fmt.Sprintf("%d", int64(totalCpu))
 						annotations[updateCountAnnotationKey] = strconv.Itoa(updateCount + 1)
 						pod.SetAnnotations(annotations)
-						err = c.Update(ctx, pod)
-						if err != nil {
-							return nil
+						if !sweep.DryRun(cleaner) {
+							err = c.Update(ctx, pod)
+							if err != nil {
+								return nil
+							}
 						}
 					} else {
 						// means this pod has some variations in cpu usuage and is not right candidate to be deleted
@@ -173,21 +229,17 @@ func DeleteAllUnusedPods(ctx context.Context, c client.Client, cleaner v1.Resour
 						delete(annotations, cpuAnnotationKey)
 						delete(annotations, updateCountAnnotationKey)
 						pod.SetAnnotations(annotations)
-						err = c.Update(ctx, pod)
-						if err != nil {
-							return nil
+						if !sweep.DryRun(cleaner) {
+							err = c.Update(ctx, pod)
+							if err != nil {
+								return nil
+							}
 						}
 					}
 
 					// If update count exceeds deletion threshold, delete the pod
 					if updateCount >= deletionThreshold {
-						if cleaner.Spec.Resources.Backup {
-							err := filesUtil.CreateFile(pod, "pods", pod.Name, cleaner)
-							if err != nil {
-								errors = append(errors, err)
-							}
-						}
-						err = c.Delete(ctx, pod)
+						err = act(ctx, c, cleaner, rep, &ns, pod, "pod cpu usage has been idle for "+strconv.Itoa(updateCount)+" checks", action, acted)
 						if err != nil {
 							return err
 						}
@@ -200,7 +252,7 @@ func DeleteAllUnusedPods(ctx context.Context, c client.Client, cleaner v1.Resour
 	}
 
 	if len(errors) > 0 {
-		errorsUtil.AggregateErrors(errors)
+		return errorsUtil.AggregateErrors(errors)
 	}
 
 	return nil
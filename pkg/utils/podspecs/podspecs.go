@@ -0,0 +1,188 @@
+package podspecs
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// InNamespace returns the spec of every pod in namespace and of every pod
+// template that can still create pods there, so objects only referenced by a
+// scaled down workload or an old ReplicaSet kept for rollback count as used.
+func InNamespace(ctx context.Context, c client.Client, namespace string) ([]corev1.PodSpec, error) {
+	templates, err := Templates(ctx, c, namespace)
+	if err != nil {
+		return nil, err
+	}
+	specs := make([]corev1.PodSpec, 0, len(templates))
+	for _, t := range templates {
+		specs = append(specs, t.Spec)
+	}
+	return specs, nil
+}
+
+// Templates is InNamespace with the metadata of every pod and pod template,
+// for lookups by label. Pods are returned as templates of themselves.
+func Templates(ctx context.Context, c client.Client, namespace string) ([]corev1.PodTemplateSpec, error) {
+	opts := &client.ListOptions{Namespace: namespace}
+	var templates []corev1.PodTemplateSpec
+
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, opts); err != nil {
+		return nil, err
+	}
+	for _, pod := range pods.Items {
+		templates = append(templates, corev1.PodTemplateSpec{ObjectMeta: pod.ObjectMeta, Spec: pod.Spec})
+	}
+
+	deployments := &appsv1.DeploymentList{}
+	if err := c.List(ctx, deployments, opts); err != nil {
+		return nil, err
+	}
+	for _, d := range deployments.Items {
+		templates = append(templates, d.Spec.Template)
+	}
+
+	replicaSets := &appsv1.ReplicaSetList{}
+	if err := c.List(ctx, replicaSets, opts); err != nil {
+		return nil, err
+	}
+	for _, rs := range replicaSets.Items {
+		templates = append(templates, rs.Spec.Template)
+	}
+
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := c.List(ctx, statefulSets, opts); err != nil {
+		return nil, err
+	}
+	for _, sts := range statefulSets.Items {
+		templates = append(templates, sts.Spec.Template)
+	}
+
+	daemonSets := &appsv1.DaemonSetList{}
+	if err := c.List(ctx, daemonSets, opts); err != nil {
+		return nil, err
+	}
+	for _, ds := range daemonSets.Items {
+		templates = append(templates, ds.Spec.Template)
+	}
+
+	jobs := &batchv1.JobList{}
+	if err := c.List(ctx, jobs, opts); err != nil {
+		return nil, err
+	}
+	for _, job := range jobs.Items {
+		templates = append(templates, job.Spec.Template)
+	}
+
+	cronJobs := &batchv1.CronJobList{}
+	if err := c.List(ctx, cronJobs, opts); err != nil {
+		return nil, err
+	}
+	for _, cj := range cronJobs.Items {
+		templates = append(templates, cj.Spec.JobTemplate.Spec.Template)
+	}
+
+	return templates, nil
+}
+
+// containers returns the init, regular and ephemeral containers of spec as
+// plain containers, which is all reference lookups need.
+func containers(spec corev1.PodSpec) []corev1.Container {
+	all := append([]corev1.Container{}, spec.InitContainers...)
+	all = append(all, spec.Containers...)
+	for _, ec := range spec.EphemeralContainers {
+		all = append(all, corev1.Container(ec.EphemeralContainerCommon))
+	}
+	return all
+}
+
+// ConfigMaps returns the names of the ConfigMaps spec references through
+// volumes, projected volumes, envFrom and env valueFrom.
+func ConfigMaps(spec corev1.PodSpec) []string {
+	var names []string
+	for _, volume := range spec.Volumes {
+		if volume.ConfigMap != nil {
+			names = append(names, volume.ConfigMap.Name)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					names = append(names, source.ConfigMap.Name)
+				}
+			}
+		}
+	}
+	for _, container := range containers(spec) {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				names = append(names, envFrom.ConfigMapRef.Name)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
+				names = append(names, env.ValueFrom.ConfigMapKeyRef.Name)
+			}
+		}
+	}
+	return names
+}
+
+// Secrets returns the names of the Secrets spec references through volumes,
+// projected volumes, envFrom, env valueFrom and imagePullSecrets.
+func Secrets(spec corev1.PodSpec) []string {
+	var names []string
+	for _, ref := range spec.ImagePullSecrets {
+		names = append(names, ref.Name)
+	}
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil {
+			names = append(names, volume.Secret.SecretName)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil {
+					names = append(names, source.Secret.Name)
+				}
+			}
+		}
+	}
+	for _, container := range containers(spec) {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil {
+				names = append(names, envFrom.SecretRef.Name)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				names = append(names, env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+	return names
+}
+
+// PersistentVolumeClaims returns the names of the claims spec mounts.
+func PersistentVolumeClaims(spec corev1.PodSpec) []string {
+	var names []string
+	for _, volume := range spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			names = append(names, volume.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	return names
+}
+
+// ServiceAccount returns the name of the ServiceAccount spec runs as.
+func ServiceAccount(spec corev1.PodSpec) string {
+	switch {
+	case spec.ServiceAccountName != "":
+		return spec.ServiceAccountName
+	case spec.DeprecatedServiceAccount != "":
+		return spec.DeprecatedServiceAccount
+	}
+	return "default"
+}
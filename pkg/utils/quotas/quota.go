@@ -0,0 +1,178 @@
+package quotas
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/podspecs"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultUnusedFor = 7 * 24 * time.Hour
+	emptyReason      = "namespace has no pods or pod templates"
+)
+
+// quotaCleaner returns the cleaner quotas and limit ranges are swept with:
+// they have to stay unused for the quota policy's UnusedFor first.
+func quotaCleaner(cleaner v1.ResourceCleaner) v1.ResourceCleaner {
+	unusedFor := defaultUnusedFor
+	if cleaner.Spec.Quotas != nil && cleaner.Spec.Quotas.UnusedFor != nil {
+		unusedFor = cleaner.Spec.Quotas.UnusedFor.Duration
+	}
+	return sweep.WithMinGrace(cleaner, unusedFor)
+}
+
+// act deletes obj or only flags it, as the quota policy says.
+func act(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report, kind v1.ResourceNames, obj client.Object, reason string) error {
+	if cleaner.Spec.Quotas != nil && cleaner.Spec.Quotas.Action == v1.Delete {
+		return sweep.Delete(ctx, c, cleaner, rep, kind, obj, reason)
+	}
+	return sweep.Flag(ctx, c, cleaner, rep, kind, obj, reason)
+}
+
+// empty reports whether nothing in namespace runs or can create pods.
+func empty(ctx context.Context, c client.Client, namespace string) (bool, error) {
+	templates, err := podspecs.Templates(ctx, c, namespace)
+	if err != nil {
+		return false, err
+	}
+	return len(templates) == 0, nil
+}
+
+func HandleAllUnusedResourceQuotas(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	s := scope.For(cleaner, v1.ResourceQuota)
+	namespaces, err := s.Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	cleaner = quotaCleaner(cleaner)
+	for _, ns := range namespaces {
+		ns := ns
+		if scope.SystemNamespace(ns.Name) {
+			continue
+		}
+		idle, err := empty(ctx, c, ns.Name)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		quotas := &corev1.ResourceQuotaList{}
+		if err := c.List(ctx, quotas, s.ListOptions(ns.Name)); err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		for _, quota := range quotas.Items {
+			quota := quota
+			// a quota allowing nothing is a guardrail, not a leftover
+			if !s.Contains(&ns, &quota) || len(quota.OwnerReferences) > 0 || denies(quota) {
+				continue
+			}
+
+			reason := ""
+			switch {
+			case idle:
+				reason = emptyReason
+			case nothingUsed(quota):
+				reason = "nothing is used of the quota"
+			}
+			if reason == "" {
+				err = sweep.Recover(ctx, c, cleaner, rep, v1.ResourceQuota, &quota)
+			} else {
+				err = act(ctx, c, cleaner, rep, v1.ResourceQuota, &quota, reason)
+			}
+			if err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+// nothingUsed reports whether every resource quota tracks is at zero. Quotas
+// the quota controller has not counted yet are not.
+func nothingUsed(quota corev1.ResourceQuota) bool {
+	if len(quota.Status.Used) == 0 {
+		return false
+	}
+	for _, used := range quota.Status.Used {
+		if !used.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// denies reports whether every hard limit of quota is zero, which keeps the
+// namespace from creating anything the quota counts.
+func denies(quota corev1.ResourceQuota) bool {
+	if len(quota.Spec.Hard) == 0 {
+		return false
+	}
+	for _, hard := range quota.Spec.Hard {
+		if !hard.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+func HandleAllUnusedLimitRanges(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	s := scope.For(cleaner, v1.LimitRange)
+	namespaces, err := s.Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	cleaner = quotaCleaner(cleaner)
+	for _, ns := range namespaces {
+		ns := ns
+		if scope.SystemNamespace(ns.Name) {
+			continue
+		}
+		idle, err := empty(ctx, c, ns.Name)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		limitRanges := &corev1.LimitRangeList{}
+		if err := c.List(ctx, limitRanges, s.ListOptions(ns.Name)); err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		for _, lr := range limitRanges.Items {
+			lr := lr
+			if !s.Contains(&ns, &lr) || len(lr.OwnerReferences) > 0 {
+				continue
+			}
+
+			if idle {
+				err = act(ctx, c, cleaner, rep, v1.LimitRange, &lr, emptyReason)
+			} else {
+				err = sweep.Recover(ctx, c, cleaner, rep, v1.LimitRange, &lr)
+			}
+			if err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+package rbac
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// systemPrefix names the roles and bindings Kubernetes manages itself.
+	systemPrefix = "system:"
+	// bootstrapLabel marks the default roles the API server reconciles, such
+	// as cluster-admin, admin, edit and view.
+	bootstrapLabel = "kubernetes.io/bootstrapping"
+	// aggregateLabelPrefix marks ClusterRoles whose rules are aggregated into
+	// another ClusterRole.
+	aggregateLabelPrefix = "rbac.authorization.k8s.io/aggregate-to-"
+)
+
+// index holds what the RBAC handlers look objects up in, across the cluster.
+type index struct {
+	roles             map[client.ObjectKey]bool
+	clusterRoles      map[string]bool
+	accounts          map[client.ObjectKey]bool
+	boundRoles        map[client.ObjectKey]bool
+	boundClusterRoles map[string]bool
+}
+
+func buildIndex(ctx context.Context, c client.Client) (*index, error) {
+	idx := &index{
+		roles:             make(map[client.ObjectKey]bool),
+		clusterRoles:      make(map[string]bool),
+		accounts:          make(map[client.ObjectKey]bool),
+		boundRoles:        make(map[client.ObjectKey]bool),
+		boundClusterRoles: make(map[string]bool),
+	}
+
+	roles := &rbacv1.RoleList{}
+	if err := c.List(ctx, roles); err != nil {
+		return nil, err
+	}
+	for _, role := range roles.Items {
+		idx.roles[client.ObjectKey{Namespace: role.Namespace, Name: role.Name}] = true
+	}
+
+	clusterRoles := &rbacv1.ClusterRoleList{}
+	if err := c.List(ctx, clusterRoles); err != nil {
+		return nil, err
+	}
+	for _, role := range clusterRoles.Items {
+		idx.clusterRoles[role.Name] = true
+	}
+
+	accounts := &corev1.ServiceAccountList{}
+	if err := c.List(ctx, accounts); err != nil {
+		return nil, err
+	}
+	for _, sa := range accounts.Items {
+		idx.accounts[client.ObjectKey{Namespace: sa.Namespace, Name: sa.Name}] = true
+	}
+
+	bindings := &rbacv1.RoleBindingList{}
+	if err := c.List(ctx, bindings); err != nil {
+		return nil, err
+	}
+	for _, binding := range bindings.Items {
+		idx.bind(binding.RoleRef, binding.Namespace)
+	}
+
+	clusterBindings := &rbacv1.ClusterRoleBindingList{}
+	if err := c.List(ctx, clusterBindings); err != nil {
+		return nil, err
+	}
+	for _, binding := range clusterBindings.Items {
+		idx.bind(binding.RoleRef, "")
+	}
+
+	return idx, nil
+}
+
+func (idx *index) bind(ref rbacv1.RoleRef, namespace string) {
+	if ref.Kind == "ClusterRole" {
+		idx.boundClusterRoles[ref.Name] = true
+		return
+	}
+	idx.boundRoles[client.ObjectKey{Namespace: namespace, Name: ref.Name}] = true
+}
+
+// danglingReason returns why a binding in namespace grants nothing, or "" to
+// keep it. Bindings with a User or Group subject are always kept, since
+// those cannot be looked up in the cluster.
+func (idx *index) danglingReason(ref rbacv1.RoleRef, subjects []rbacv1.Subject, namespace string) string {
+	switch ref.Kind {
+	case "Role":
+		if !idx.roles[client.ObjectKey{Namespace: namespace, Name: ref.Name}] {
+			return "bound role " + ref.Name + " does not exist"
+		}
+	case "ClusterRole":
+		if !idx.clusterRoles[ref.Name] {
+			return "bound cluster role " + ref.Name + " does not exist"
+		}
+	}
+
+	if len(subjects) == 0 {
+		return "binding has no subjects"
+	}
+	for _, subject := range subjects {
+		if subject.Kind != rbacv1.ServiceAccountKind {
+			return ""
+		}
+		key := client.ObjectKey{Namespace: subject.Namespace, Name: subject.Name}
+		if key.Namespace == "" {
+			key.Namespace = namespace
+		}
+		if idx.accounts[key] {
+			return ""
+		}
+	}
+	return "none of the bound service accounts exist"
+}
+
+// managed reports whether obj belongs to Kubernetes or to a controller and
+// is never swept.
+func managed(obj client.Object) bool {
+	return strings.HasPrefix(obj.GetName(), systemPrefix) ||
+		obj.GetLabels()[bootstrapLabel] != "" ||
+		len(obj.GetOwnerReferences()) > 0
+}
+
+// aggregated reports whether role aggregates other ClusterRoles or is
+// aggregated into one, which binds it without a binding.
+func aggregated(role rbacv1.ClusterRole) bool {
+	if role.AggregationRule != nil {
+		return true
+	}
+	for key := range role.Labels {
+		if strings.HasPrefix(key, aggregateLabelPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleAll sweeps dangling RoleBindings and ClusterRoleBindings and unused
+// Roles and ClusterRoles, for the kinds in scope. The cluster's RBAC objects
+// are indexed once for all of them.
+func HandleAll(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	enabled := false
+	for _, kind := range []v1.ResourceNames{v1.RoleBinding, v1.ClusterRoleBinding, v1.Role, v1.ClusterRole} {
+		enabled = enabled || scope.For(cleaner, kind).Enabled()
+	}
+	if !enabled {
+		return nil
+	}
+	idx, err := buildIndex(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	var errors []error
+	for _, handle := range []func(context.Context, client.Client, v1.ResourceCleaner, *sweep.Report, *index) error{
+		handleDanglingRoleBindings,
+		handleDanglingClusterRoleBindings,
+		handleUnusedRoles,
+		handleUnusedClusterRoles,
+	} {
+		if err := handle(ctx, c, cleaner, rep, idx); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleDanglingRoleBindings(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report, idx *index) error {
+	var errors []error
+	s := scope.For(cleaner, v1.RoleBinding)
+	namespaces, err := s.Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		ns := ns
+		if scope.SystemNamespace(ns.Name) {
+			continue
+		}
+		bindings := &rbacv1.RoleBindingList{}
+		if err := c.List(ctx, bindings, s.ListOptions(ns.Name)); err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		for _, binding := range bindings.Items {
+			binding := binding
+			if !s.Contains(&ns, &binding) || managed(&binding) {
+				continue
+			}
+			reason := idx.danglingReason(binding.RoleRef, binding.Subjects, binding.Namespace)
+			if err := sweepOrRecover(ctx, c, cleaner, rep, v1.RoleBinding, &binding, reason, true); err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleDanglingClusterRoleBindings(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report, idx *index) error {
+	var errors []error
+	s := scope.For(cleaner, v1.ClusterRoleBinding)
+	if !s.Enabled() {
+		return s.Err()
+	}
+
+	bindings := &rbacv1.ClusterRoleBindingList{}
+	if err := c.List(ctx, bindings, s.ListOptions("")); err != nil {
+		return err
+	}
+	for _, binding := range bindings.Items {
+		binding := binding
+		if !s.Contains(nil, &binding) || managed(&binding) {
+			continue
+		}
+		reason := idx.danglingReason(binding.RoleRef, binding.Subjects, "")
+		if err := sweepOrRecover(ctx, c, cleaner, rep, v1.ClusterRoleBinding, &binding, reason, true); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleUnusedRoles(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report, idx *index) error {
+	var errors []error
+	s := scope.For(cleaner, v1.Role)
+	namespaces, err := s.Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		ns := ns
+		if scope.SystemNamespace(ns.Name) {
+			continue
+		}
+		roles := &rbacv1.RoleList{}
+		if err := c.List(ctx, roles, s.ListOptions(ns.Name)); err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		for _, role := range roles.Items {
+			role := role
+			if !s.Contains(&ns, &role) || managed(&role) {
+				continue
+			}
+			reason := ""
+			if !idx.boundRoles[client.ObjectKeyFromObject(&role)] {
+				reason = "role is not bound by any role binding"
+			}
+			if err := sweepOrRecover(ctx, c, cleaner, rep, v1.Role, &role, reason, s.Listed()); err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleUnusedClusterRoles(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report, idx *index) error {
+	var errors []error
+	s := scope.For(cleaner, v1.ClusterRole)
+	if !s.Enabled() {
+		return s.Err()
+	}
+
+	roles := &rbacv1.ClusterRoleList{}
+	if err := c.List(ctx, roles, s.ListOptions("")); err != nil {
+		return err
+	}
+	for _, role := range roles.Items {
+		role := role
+		if !s.Contains(nil, &role) || managed(&role) || aggregated(role) {
+			continue
+		}
+		reason := ""
+		if !idx.boundClusterRoles[role.Name] {
+			reason = "cluster role is not bound by any role binding or cluster role binding"
+		}
+		if err := sweepOrRecover(ctx, c, cleaner, rep, v1.ClusterRole, &role, reason, s.Listed()); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+// sweepOrRecover deletes obj when there is a reason to, or only flags it
+// unless del is set, and removes its candidate mark otherwise. Unbound roles
+// are often shipped for admins to bind, so they are only deleted when their
+// kind is included explicitly.
+func sweepOrRecover(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report, kind v1.ResourceNames, obj client.Object, reason string, del bool) error {
+	switch {
+	case reason == "":
+		return sweep.Recover(ctx, c, cleaner, rep, kind, obj)
+	case del:
+		return sweep.Delete(ctx, c, cleaner, rep, kind, obj, reason)
+	}
+	return sweep.Flag(ctx, c, cleaner, rep, kind, obj, reason)
+}
@@ -0,0 +1,95 @@
+package rbac
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestDanglingReason(t *testing.T) {
+	idx := &index{
+		roles:        map[client.ObjectKey]bool{{Namespace: "dev", Name: "reader"}: true},
+		clusterRoles: map[string]bool{"view": true},
+		accounts:     map[client.ObjectKey]bool{{Namespace: "dev", Name: "app"}: true},
+	}
+	account := func(namespace, name string) rbacv1.Subject {
+		return rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: namespace, Name: name}
+	}
+	role := rbacv1.RoleRef{Kind: "Role", Name: "reader"}
+	view := rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"}
+
+	tests := []struct {
+		name      string
+		ref       rbacv1.RoleRef
+		subjects  []rbacv1.Subject
+		namespace string
+		want      string
+	}{
+		{
+			name:      "role and account exist",
+			ref:       role,
+			subjects:  []rbacv1.Subject{account("dev", "app")},
+			namespace: "dev",
+		},
+		{
+			name:      "subject namespace defaults to the binding's",
+			ref:       role,
+			subjects:  []rbacv1.Subject{account("", "app")},
+			namespace: "dev",
+		},
+		{
+			name:      "role of another namespace",
+			ref:       role,
+			subjects:  []rbacv1.Subject{account("dev", "app")},
+			namespace: "prod",
+			want:      "bound role reader does not exist",
+		},
+		{
+			name:      "missing cluster role",
+			ref:       rbacv1.RoleRef{Kind: "ClusterRole", Name: "gone"},
+			subjects:  []rbacv1.Subject{account("dev", "app")},
+			namespace: "dev",
+			want:      "bound cluster role gone does not exist",
+		},
+		{
+			name:      "no subjects",
+			ref:       view,
+			namespace: "dev",
+			want:      "binding has no subjects",
+		},
+		{
+			name:      "every account missing",
+			ref:       view,
+			subjects:  []rbacv1.Subject{account("dev", "old"), account("prod", "app")},
+			namespace: "dev",
+			want:      "none of the bound service accounts exist",
+		},
+		{
+			name:      "one account left",
+			ref:       view,
+			subjects:  []rbacv1.Subject{account("dev", "old"), account("dev", "app")},
+			namespace: "dev",
+		},
+		{
+			name:      "users and groups cannot be checked",
+			ref:       view,
+			subjects:  []rbacv1.Subject{account("dev", "old"), {Kind: rbacv1.UserKind, Name: "jane"}},
+			namespace: "dev",
+		},
+		{
+			name:     "cluster binding needs subject namespaces",
+			ref:      view,
+			subjects: []rbacv1.Subject{account("", "app")},
+			want:     "none of the bound service accounts exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := idx.danglingReason(tt.ref, tt.subjects, tt.namespace); got != tt.want {
+				t.Errorf("danglingReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package replicasets
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultHistoryLimit = 3
+	// revisionAnnotation holds the Deployment revision a ReplicaSet belongs to.
+	revisionAnnotation = "deployment.kubernetes.io/revision"
+)
+
+func HandleAllOldReplicaSets(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	namespaces, err := scope.For(cleaner, v1.ReplicaSet).Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	// old ReplicaSets are what rollbacks restore, so they are always backed up
+	cleaner.Spec.Resources.Backup = true
+	for _, ns := range namespaces {
+		if scope.SystemNamespace(ns.Name) {
+			continue
+		}
+		if err := handleOldReplicaSetsInNamespace(ctx, c, ns, cleaner, rep); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleOldReplicaSetsInNamespace(ctx context.Context, c client.Client, ns corev1.Namespace, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	s := scope.For(cleaner, v1.ReplicaSet)
+
+	replicaSets := &appsv1.ReplicaSetList{}
+	if err := c.List(ctx, replicaSets, &client.ListOptions{Namespace: ns.Name}); err != nil {
+		return err
+	}
+	pruned := prunable(replicaSets.Items, historyLimit(cleaner))
+
+	for _, rs := range replicaSets.Items {
+		rs := rs
+		if !s.Contains(&ns, &rs) {
+			continue
+		}
+
+		reason, ok := pruned[rs.UID]
+		if !ok {
+			if err := sweep.Recover(ctx, c, cleaner, rep, v1.ReplicaSet, &rs); err != nil {
+				errors = append(errors, err)
+			}
+			continue
+		}
+		if err := sweep.Delete(ctx, c, cleaner, rep, v1.ReplicaSet, &rs, reason); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func historyLimit(cleaner v1.ResourceCleaner) int {
+	if cleaner.Spec.ReplicaSets != nil && cleaner.Spec.ReplicaSets.HistoryLimit != nil {
+		return int(*cleaner.Spec.ReplicaSets.HistoryLimit)
+	}
+	return defaultHistoryLimit
+}
+
+// prunable returns the ReplicaSets among replicaSets that can go, with the
+// reason. Only ReplicaSets without replicas are considered: the ones without
+// an owner, and the ones of a Deployment beyond the newest limit old
+// revisions. The current revision of a Deployment is always kept, even when
+// it is scaled to zero, and ReplicaSets of other controllers are left alone.
+func prunable(replicaSets []appsv1.ReplicaSet, limit int) map[types.UID]string {
+	pruned := make(map[types.UID]string)
+	byDeployment := make(map[types.UID][]appsv1.ReplicaSet)
+	for _, rs := range replicaSets {
+		owner := metav1.GetControllerOf(&rs)
+		switch {
+		case owner == nil:
+			if idle(rs) {
+				pruned[rs.UID] = "replica set has no replicas and no owner"
+			}
+		case owner.Kind == "Deployment":
+			byDeployment[owner.UID] = append(byDeployment[owner.UID], rs)
+		}
+	}
+
+	for _, history := range byDeployment {
+		sort.Slice(history, func(i, j int) bool {
+			return revision(history[i]) > revision(history[j])
+		})
+		// history[0] is the current revision
+		for i, rs := range history {
+			if i > limit && idle(rs) {
+				pruned[rs.UID] = "revision " + strconv.FormatInt(revision(rs), 10) +
+					" is beyond the " + strconv.Itoa(limit) + " old revisions kept"
+			}
+		}
+	}
+	return pruned
+}
+
+func idle(rs appsv1.ReplicaSet) bool {
+	return rs.Spec.Replicas != nil && *rs.Spec.Replicas == 0 && rs.Status.Replicas == 0
+}
+
+func revision(rs appsv1.ReplicaSet) int64 {
+	r, err := strconv.ParseInt(rs.Annotations[revisionAnnotation], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return r
+}
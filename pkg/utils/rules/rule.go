@@ -0,0 +1,305 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/owners"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// HandleAllRules runs every rule of the cleaner. Objects are read as
+// unstructured, so rules work for any kind the API server serves.
+func HandleAllRules(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	lookup := owners.NewLookup(c)
+	markers := make(map[string]bool)
+	for _, rule := range cleaner.Spec.Rules {
+		markers[marker(rule)] = true
+	}
+	for _, rule := range cleaner.Spec.Rules {
+		if err := handleRule(ctx, c, cleaner, rep, rule, lookup, markers); err != nil {
+			errors = append(errors, fmt.Errorf("rule for %s %s: %w", rule.APIVersion, rule.Name, err))
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+// handleRule sweeps the objects rule matches. markers are the marker names of
+// the cleaner's current rules, the marks of rules since edited or removed are
+// cleared from the objects it sees.
+func handleRule(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report, rule v1.Rule, lookup *owners.Lookup, markers map[string]bool) error {
+	var errors []error
+	s := scope.ForRule(cleaner, rule.Resource)
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	gv, err := schema.ParseGroupVersion(rule.APIVersion)
+	if err != nil {
+		return err
+	}
+	gvk := gv.WithKind(rule.Name)
+	mapping, err := c.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return err
+	}
+	test, err := compile(rule.Conditions)
+	if err != nil {
+		return err
+	}
+
+	namespaced := mapping.Scope.Name() == meta.RESTScopeNameNamespace
+	// namespaces are scoped by their own name and labels, like scope.For does
+	isNamespace := gvk.Group == "" && gvk.Kind == "Namespace"
+	if !namespaced && !isNamespace && (rule.Namespace != "" || rule.NamespaceSelector != nil) {
+		return fmt.Errorf("namespace and namespaceSelector do not apply to cluster scoped %s", rule.Name)
+	}
+	ctx = sweep.WithMarker(ctx, marker(rule))
+
+	// cluster scoped kinds are listed once, without a namespace
+	namespaces := []corev1.Namespace{{}}
+	if namespaced {
+		if namespaces, err = s.Namespaces(ctx, c); err != nil {
+			return err
+		}
+	}
+
+	kind := v1.ResourceNames(rule.Name)
+	for _, ns := range namespaces {
+		ns := ns
+		if scope.SystemNamespace(ns.Name) {
+			continue
+		}
+		nsRef := &ns
+		if ns.Name == "" {
+			nsRef = nil
+		}
+
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gv.WithKind(rule.Name + "List"))
+		if err := c.List(ctx, list, s.ListOptions(ns.Name)); err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		for _, obj := range list.Items {
+			obj := obj
+			if isNamespace {
+				if obj.GetName() == "default" || scope.SystemNamespace(obj.GetName()) {
+					continue
+				}
+				nsRef = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: obj.GetName(), Labels: obj.GetLabels()}}
+			}
+			if !s.Contains(nsRef, &obj) {
+				continue
+			}
+			if err := sweep.PruneMarkers(ctx, c, cleaner, rep, kind, &obj, markerPrefix, markers); err != nil {
+				errors = append(errors, err)
+				continue
+			}
+
+			reason, err := test(ctx, c, lookup, &obj)
+			if err != nil {
+				errors = append(errors, err)
+				continue
+			}
+			switch {
+			case reason == "":
+				err = sweep.Recover(ctx, c, cleaner, rep, kind, &obj)
+			case rule.Action == v1.Delete:
+				err = sweep.Delete(ctx, c, cleaner, rep, kind, &obj, reason)
+			default:
+				err = sweep.Flag(ctx, c, cleaner, rep, kind, &obj, reason)
+			}
+			if err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+// markerPrefix starts the marker names of every rule.
+const markerPrefix = "rule-"
+
+// marker names the candidate marks of rule. It changes with the rule, so an
+// edited rule starts its grace period over.
+func marker(rule v1.Rule) string {
+	h := fnv.New32a()
+	spec, _ := json.Marshal(rule)
+	h.Write(spec)
+	return fmt.Sprintf("%s%08x", markerPrefix, h.Sum32())
+}
+
+// test returns why obj meets every condition of a rule, or "" if it misses
+// any.
+type test func(ctx context.Context, c client.Client, lookup *owners.Lookup, obj *unstructured.Unstructured) (string, error)
+
+// compile parses the JSONPaths of conditions once and returns the test they
+// make up together.
+func compile(conditions []v1.RuleCondition) (test, error) {
+	var tests []test
+	for i, cond := range conditions {
+		cond := cond
+		if cond.OlderThan != nil {
+			tests = append(tests, olderThan(cond.OlderThan.Duration))
+		}
+		if cond.OwnerMissing {
+			tests = append(tests, ownerMissing)
+		}
+		// a condition that tests nothing would make the rule match more than
+		// it says
+		if cond.Equals == nil && cond.References == nil {
+			if cond.Field != "" {
+				return nil, fmt.Errorf("condition %d: field needs equals or references", i)
+			}
+			if cond.OlderThan == nil && !cond.OwnerMissing {
+				return nil, fmt.Errorf("condition %d tests nothing", i)
+			}
+			continue
+		}
+
+		path, err := parseField(cond.Field)
+		if err != nil {
+			return nil, err
+		}
+		if cond.Equals != nil {
+			tests = append(tests, equals(cond.Field, path, *cond.Equals))
+		}
+		if cond.References != nil {
+			tests = append(tests, references(cond.Field, path, *cond.References))
+		}
+	}
+	if len(tests) == 0 {
+		return nil, fmt.Errorf("rule has no conditions")
+	}
+
+	return func(ctx context.Context, c client.Client, lookup *owners.Lookup, obj *unstructured.Unstructured) (string, error) {
+		var reasons []string
+		for _, t := range tests {
+			reason, err := t(ctx, c, lookup, obj)
+			if err != nil || reason == "" {
+				return "", err
+			}
+			reasons = append(reasons, reason)
+		}
+		return strings.Join(reasons, "; "), nil
+	}, nil
+}
+
+func olderThan(age time.Duration) test {
+	return func(_ context.Context, _ client.Client, _ *owners.Lookup, obj *unstructured.Unstructured) (string, error) {
+		created := obj.GetCreationTimestamp()
+		if time.Since(created.Time) < age {
+			return "", nil
+		}
+		return "created at " + created.UTC().Format(time.RFC3339) + ", older than " + age.String(), nil
+	}
+}
+
+func ownerMissing(ctx context.Context, _ client.Client, lookup *owners.Lookup, obj *unstructured.Unstructured) (string, error) {
+	refs := obj.GetOwnerReferences()
+	if len(refs) == 0 {
+		return "", nil
+	}
+	missing, err := lookup.Missing(ctx, obj)
+	if err != nil || len(missing) < len(refs) {
+		return "", err
+	}
+	var gone []string
+	for _, ref := range missing {
+		gone = append(gone, owners.Describe(ref))
+	}
+	return "owners are gone: " + strings.Join(gone, ", "), nil
+}
+
+func equals(field string, path *jsonpath.JSONPath, value string) test {
+	return func(_ context.Context, _ client.Client, _ *owners.Lookup, obj *unstructured.Unstructured) (string, error) {
+		got, err := evaluate(path, obj)
+		if err != nil || got != value {
+			return "", err
+		}
+		return field + " is " + value, nil
+	}
+}
+
+func references(field string, path *jsonpath.JSONPath, kind v1.ObjectKind) test {
+	return func(ctx context.Context, c client.Client, _ *owners.Lookup, obj *unstructured.Unstructured) (string, error) {
+		name, err := evaluate(path, obj)
+		if err != nil || name == "" {
+			return "", err
+		}
+
+		gv, err := schema.ParseGroupVersion(kind.APIVersion)
+		if err != nil {
+			return "", err
+		}
+		gvk := gv.WithKind(kind.Kind)
+		mapping, err := c.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return "", err
+		}
+		key := client.ObjectKey{Name: name}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			key.Namespace = obj.GetNamespace()
+		}
+
+		target := &unstructured.Unstructured{}
+		target.SetGroupVersionKind(gvk)
+		if err := c.Get(ctx, key, target); err != nil {
+			if apierrors.IsNotFound(err) {
+				return field + " names " + kind.Kind + " " + name + " which does not exist", nil
+			}
+			return "", err
+		}
+		return "", nil
+	}
+}
+
+// parseField parses a JSONPath, with or without the surrounding braces.
+func parseField(field string) (*jsonpath.JSONPath, error) {
+	if field == "" {
+		return nil, fmt.Errorf("equals and references need a field")
+	}
+	if !strings.HasPrefix(field, "{") {
+		field = "{" + field + "}"
+	}
+	path := jsonpath.New("field").AllowMissingKeys(true)
+	if err := path.Parse(field); err != nil {
+		return nil, fmt.Errorf("invalid field %q: %w", field, err)
+	}
+	return path, nil
+}
+
+// evaluate renders path on obj as text, "" when the field is missing.
+func evaluate(path *jsonpath.JSONPath, obj *unstructured.Unstructured) (string, error) {
+	var out bytes.Buffer
+	if err := path.Execute(&out, obj.Object); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
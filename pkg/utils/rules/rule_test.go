@@ -0,0 +1,290 @@
+package rules
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"kubefit.com/kubeswipe/pkg/utils/owners"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCompile(t *testing.T) {
+	failed := "Failed"
+	hour := &metav1.Duration{Duration: time.Hour}
+
+	pod := func(age time.Duration, phase string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"status":     map[string]interface{}{"phase": phase},
+		}}
+		obj.SetName("app")
+		obj.SetNamespace("dev")
+		obj.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-age)))
+		return obj
+	}
+
+	tests := []struct {
+		name       string
+		conditions []v1.RuleCondition
+		obj        *unstructured.Unstructured
+		wantErr    bool
+		wantMatch  bool
+	}{
+		{
+			name:    "no conditions",
+			wantErr: true,
+		},
+		{
+			name:       "empty condition",
+			conditions: []v1.RuleCondition{{}},
+			wantErr:    true,
+		},
+		{
+			name:       "empty condition next to a valid one",
+			conditions: []v1.RuleCondition{{OlderThan: hour}, {}},
+			wantErr:    true,
+		},
+		{
+			name:       "field without equals or references",
+			conditions: []v1.RuleCondition{{Field: "{.status.phase}"}},
+			wantErr:    true,
+		},
+		{
+			name:       "equals without field",
+			conditions: []v1.RuleCondition{{Equals: &failed}},
+			wantErr:    true,
+		},
+		{
+			name:       "invalid field",
+			conditions: []v1.RuleCondition{{Field: "{.status[", Equals: &failed}},
+			wantErr:    true,
+		},
+		{
+			name:       "field equals",
+			conditions: []v1.RuleCondition{{Field: "{.status.phase}", Equals: &failed}},
+			obj:        pod(0, "Failed"),
+			wantMatch:  true,
+		},
+		{
+			name:       "field without braces",
+			conditions: []v1.RuleCondition{{Field: ".status.phase", Equals: &failed}},
+			obj:        pod(0, "Failed"),
+			wantMatch:  true,
+		},
+		{
+			name:       "field differs",
+			conditions: []v1.RuleCondition{{Field: "{.status.phase}", Equals: &failed}},
+			obj:        pod(0, "Running"),
+			wantMatch:  false,
+		},
+		{
+			name:       "missing field differs",
+			conditions: []v1.RuleCondition{{Field: "{.status.reason}", Equals: &failed}},
+			obj:        pod(0, "Failed"),
+			wantMatch:  false,
+		},
+		{
+			name:       "older than",
+			conditions: []v1.RuleCondition{{OlderThan: hour}},
+			obj:        pod(2*time.Hour, "Running"),
+			wantMatch:  true,
+		},
+		{
+			name:       "too young",
+			conditions: []v1.RuleCondition{{OlderThan: hour}},
+			obj:        pod(time.Minute, "Running"),
+			wantMatch:  false,
+		},
+		{
+			name:       "every condition has to hold",
+			conditions: []v1.RuleCondition{{OlderThan: hour}, {Field: "{.status.phase}", Equals: &failed}},
+			obj:        pod(2*time.Hour, "Running"),
+			wantMatch:  false,
+		},
+		{
+			name:       "conditions of one entry are combined",
+			conditions: []v1.RuleCondition{{OlderThan: hour, Field: "{.status.phase}", Equals: &failed}},
+			obj:        pod(2*time.Hour, "Failed"),
+			wantMatch:  true,
+		},
+		{
+			name:       "owner missing on an object without owners",
+			conditions: []v1.RuleCondition{{OwnerMissing: true}},
+			obj:        pod(0, "Running"),
+			wantMatch:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test, err := compile(tt.conditions)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			reason, err := test(context.Background(), nil, nil, tt.obj)
+			if err != nil {
+				t.Fatalf("test() error = %v", err)
+			}
+			if got := reason != ""; got != tt.wantMatch {
+				t.Errorf("test() = %q, want match %v", reason, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestHandleRule(t *testing.T) {
+	old := metav1.NewTime(time.Now().Add(-48 * time.Hour))
+	namespace := func(name string) client.Object {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: old}}
+	}
+	configMap := func(namespace string) client.Object {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: namespace, CreationTimestamp: old}}
+	}
+	rule := func(apiVersion, kind, namespace string) v1.Rule {
+		return v1.Rule{
+			Resource:   v1.Resource{Name: kind, Namespace: namespace},
+			APIVersion: apiVersion,
+			Conditions: []v1.RuleCondition{{OlderThan: &metav1.Duration{Duration: time.Hour}}},
+			Action:     v1.Delete,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		rule        v1.Rule
+		wantErr     bool
+		wantDeleted []string
+	}{
+		{
+			name:        "namespaces by name",
+			rule:        rule("v1", "Namespace", "preview-*"),
+			wantDeleted: []string{"/preview-a"},
+		},
+		{
+			name:        "namespaces Kubernetes needs are left alone",
+			rule:        rule("v1", "Namespace", ""),
+			wantDeleted: []string{"/dev", "/preview-a"},
+		},
+		{
+			name:        "namespaced objects outside system namespaces",
+			rule:        rule("v1", "ConfigMap", ""),
+			wantDeleted: []string{"dev/app", "preview-a/app"},
+		},
+		{
+			name:    "namespace constraints on other cluster scoped kinds",
+			rule:    rule("rbac.authorization.k8s.io/v1", "ClusterRole", "dev"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := meta.NewDefaultRESTMapper(nil)
+			mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+			mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+			mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+			c := fake.NewClientBuilder().WithRESTMapper(mapper).WithObjects(
+				namespace("default"), namespace("kube-system"), namespace("dev"), namespace("preview-a"),
+				configMap("kube-system"), configMap("dev"), configMap("preview-a"),
+			).Build()
+
+			cleaner := v1.ResourceCleaner{}
+			cleaner.Spec.Operation = v1.CleanUp
+			rep := sweep.NewReport()
+			err := handleRule(context.Background(), c, cleaner, rep, tt.rule, owners.NewLookup(c), map[string]bool{marker(tt.rule): true})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("handleRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			status := v1.ResourceCleanerStatus{}
+			rep.WriteStatus(&status)
+			var deleted []string
+			for _, o := range status.RecentObjects {
+				if o.Action == v1.Deleted {
+					deleted = append(deleted, o.Namespace+"/"+o.Name)
+				}
+			}
+			sort.Strings(deleted)
+			if !reflect.DeepEqual(deleted, tt.wantDeleted) {
+				t.Errorf("deleted %v, want %v", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestHandleRulePrunesMarks(t *testing.T) {
+	current := v1.Rule{
+		Resource:   v1.Resource{Name: "ConfigMap"},
+		APIVersion: "v1",
+		Conditions: []v1.RuleCondition{{OlderThan: &metav1.Duration{Duration: time.Hour}}},
+	}
+	since := time.Now().UTC().Format(time.RFC3339)
+	stale := v1.CandidateSinceKey + "-rule-00000000"
+	kept := v1.CandidateSinceKey + "-" + marker(current)
+	orphans := v1.CandidateSinceKey + "-orphans"
+
+	tests := []struct {
+		name      string
+		operation v1.OperationName
+		want      map[string]bool
+	}{
+		{
+			name:      "cleanup",
+			operation: v1.CleanUp,
+			want:      map[string]bool{stale: false, kept: true, orphans: true, v1.CandidateSinceKey: true},
+		},
+		{
+			name: "dry run",
+			want: map[string]bool{stale: true, kept: true, orphans: true, v1.CandidateSinceKey: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := meta.NewDefaultRESTMapper(nil)
+			mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+			cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name:              "app",
+				Namespace:         "dev",
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-48 * time.Hour)),
+				Annotations:       map[string]string{stale: since, kept: since, orphans: since, v1.CandidateSinceKey: since},
+			}}
+			c := fake.NewClientBuilder().WithRESTMapper(mapper).WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}}, cm,
+			).Build()
+
+			cleaner := v1.ResourceCleaner{}
+			cleaner.Spec.Operation = tt.operation
+			cleaner.Spec.GracePeriod = &metav1.Duration{Duration: 24 * time.Hour}
+			cleaner.Spec.Rules = []v1.Rule{current}
+			if err := HandleAllRules(context.Background(), c, cleaner, sweep.NewReport()); err != nil {
+				t.Fatalf("HandleAllRules() error = %v", err)
+			}
+
+			stored := &corev1.ConfigMap{}
+			if err := c.Get(context.Background(), client.ObjectKeyFromObject(cm), stored); err != nil {
+				t.Fatal(err)
+			}
+			for key, want := range tt.want {
+				if _, got := stored.Annotations[key]; got != want {
+					t.Errorf("annotation %s set = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
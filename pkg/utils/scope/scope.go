@@ -0,0 +1,250 @@
+package scope
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Scope is the part of the cluster a cleaner targets for one kind, built from
+// the include and exclude entries of that kind.
+type Scope struct {
+	kind v1.ResourceNames
+	// all is set when the cleaner has no include entries at all, in which
+	// case every kind is included unless excluded.
+	all     bool
+	include []entry
+	exclude []entry
+	err     error
+}
+
+type entry struct {
+	namespace         string
+	namespaceSelector labels.Selector
+	selector          labels.Selector
+	name              *regexp.Regexp
+}
+
+// For builds the scope of kind for cleaner.
+func For(cleaner v1.ResourceCleaner, kind v1.ResourceNames) Scope {
+	s := Scope{kind: kind, all: len(cleaner.Spec.Resources.Include) == 0}
+	s.include, s.err = entries(cleaner.Spec.Resources.Include, kind)
+	if s.err != nil {
+		return s
+	}
+	s.exclude, s.err = entries(cleaner.Spec.Resources.Exclude, kind)
+	return s
+}
+
+// ForRule builds the scope of rule, whose entry is the only include. The
+// cleaner's exclude entries for the rule's kind still apply.
+func ForRule(cleaner v1.ResourceCleaner, rule v1.Resource) Scope {
+	kind := v1.ResourceNames(rule.Name)
+	s := Scope{kind: kind}
+	s.include, s.err = entries([]v1.Resource{rule}, kind)
+	if s.err != nil {
+		return s
+	}
+	s.exclude, s.err = entries(cleaner.Spec.Resources.Exclude, kind)
+	return s
+}
+
+func entries(resources []v1.Resource, kind v1.ResourceNames) ([]entry, error) {
+	var out []entry
+	for _, r := range resources {
+		if r.Name != string(kind) {
+			continue
+		}
+		if _, err := path.Match(r.Namespace, ""); err != nil {
+			return nil, fmt.Errorf("invalid namespace pattern %q for %s: %w", r.Namespace, kind, err)
+		}
+		e := entry{namespace: r.Namespace, namespaceSelector: labels.Everything()}
+		if r.NamespaceSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(r.NamespaceSelector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid namespaceSelector for %s: %w", kind, err)
+			}
+			e.namespaceSelector = selector
+		}
+		e.selector = labels.Everything()
+		if r.Selector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(r.Selector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid selector for %s: %w", kind, err)
+			}
+			e.selector = selector
+		}
+		if r.NameRegex != "" {
+			name, err := regexp.Compile(r.NameRegex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid nameRegex for %s: %w", kind, err)
+			}
+			e.name = name
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// Enabled reports whether anything of the kind can be in scope.
+func (s Scope) Enabled() bool {
+	if !s.all && len(s.include) == 0 {
+		return false
+	}
+	for _, e := range s.exclude {
+		if e.everything() {
+			return false
+		}
+	}
+	return true
+}
+
+// Listed reports whether an include entry names the kind explicitly, which
+// kinds that hold data require before they are swept.
+func (s Scope) Listed() bool {
+	return len(s.include) > 0 && s.Enabled()
+}
+
+// Err returns the error found while parsing the cleaner's entries, if any.
+func (s Scope) Err() error {
+	return s.err
+}
+
+// Namespaces returns the namespaces objects of the kind may be swept in.
+func (s Scope) Namespaces(ctx context.Context, c client.Client) ([]corev1.Namespace, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if !s.Enabled() {
+		return nil, nil
+	}
+
+	namespaces := &corev1.NamespaceList{}
+	if err := c.List(ctx, namespaces); err != nil {
+		return nil, err
+	}
+
+	var out []corev1.Namespace
+	for _, ns := range namespaces.Items {
+		if s.containsNamespace(&ns) {
+			out = append(out, ns)
+		}
+	}
+	return out, nil
+}
+
+// ListOptions returns the options to list objects of the kind in namespace.
+// The label selector is pushed to the API server when a single include entry
+// applies; Contains must still be checked on every listed object.
+func (s Scope) ListOptions(namespace string) *client.ListOptions {
+	opts := &client.ListOptions{Namespace: namespace}
+	if !s.all && len(s.include) == 1 && !s.include[0].selector.Empty() {
+		opts.LabelSelector = s.include[0].selector
+	}
+	return opts
+}
+
+// Contains reports whether obj is in scope. ns is the namespace obj lives in,
+// or obj itself for namespaces, and nil for other cluster scoped objects.
+func (s Scope) Contains(ns *corev1.Namespace, obj client.Object) bool {
+	if s.err != nil || !s.Enabled() {
+		return false
+	}
+
+	included := s.all
+	for _, e := range s.include {
+		if e.matchesNamespace(ns) && e.matchesObject(obj) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+
+	for _, e := range s.exclude {
+		// excludes bound to namespaces say nothing about cluster scoped objects
+		if ns == nil && e.namespaceConstraints() {
+			continue
+		}
+		if e.matchesNamespace(ns) && e.matchesObject(obj) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Scope) containsNamespace(ns *corev1.Namespace) bool {
+	if s.err != nil || !s.Enabled() {
+		return false
+	}
+
+	included := s.all
+	for _, e := range s.include {
+		if e.matchesNamespace(ns) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+
+	// only excludes without object constraints rule out a whole namespace
+	for _, e := range s.exclude {
+		if e.objectConstraints() {
+			continue
+		}
+		if e.matchesNamespace(ns) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e entry) everything() bool {
+	return !e.namespaceConstraints() && !e.objectConstraints()
+}
+
+func (e entry) namespaceConstraints() bool {
+	return e.namespace != "" || !e.namespaceSelector.Empty()
+}
+
+func (e entry) objectConstraints() bool {
+	return !e.selector.Empty() || e.name != nil
+}
+
+func (e entry) matchesObject(obj client.Object) bool {
+	if e.name != nil && !e.name.MatchString(obj.GetName()) {
+		return false
+	}
+	return e.selector.Matches(labels.Set(obj.GetLabels()))
+}
+
+// matchesNamespace reports whether ns satisfies the namespace constraints of
+// the entry. Cluster scoped objects have no namespace and always do.
+func (e entry) matchesNamespace(ns *corev1.Namespace) bool {
+	if ns == nil {
+		return true
+	}
+	if e.namespace != "" {
+		if ok, _ := path.Match(e.namespace, ns.Name); !ok {
+			return false
+		}
+	}
+	return e.namespaceSelector.Matches(labels.Set(ns.Labels))
+}
+
+// SystemNamespace reports whether name is one of the kube-* namespaces
+// Kubernetes manages itself.
+func SystemNamespace(name string) bool {
+	return strings.HasPrefix(name, "kube-")
+}
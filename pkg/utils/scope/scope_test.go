@@ -0,0 +1,241 @@
+package scope
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func cleanerWith(include, exclude []v1.Resource) v1.ResourceCleaner {
+	cleaner := v1.ResourceCleaner{}
+	cleaner.Spec.Resources.Include = include
+	cleaner.Spec.Resources.Exclude = exclude
+	return cleaner
+}
+
+func namespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func configMap(name string, labels map[string]string) client.Object {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestContains(t *testing.T) {
+	team := &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}
+	keep := &metav1.LabelSelector{MatchLabels: map[string]string{"keep": "true"}}
+
+	tests := []struct {
+		name    string
+		include []v1.Resource
+		exclude []v1.Resource
+		ns      *corev1.Namespace
+		obj     client.Object
+		want    bool
+	}{
+		{
+			name: "no includes take every kind",
+			ns:   namespace("dev", nil),
+			obj:  configMap("app", nil),
+			want: true,
+		},
+		{
+			name:    "includes of other kinds leave the kind out",
+			include: []v1.Resource{{Name: string(v1.Secret)}},
+			ns:      namespace("dev", nil),
+			obj:     configMap("app", nil),
+			want:    false,
+		},
+		{
+			name:    "namespace glob matches",
+			include: []v1.Resource{{Name: string(v1.ConfigMap), Namespace: "team-*"}},
+			ns:      namespace("team-a", nil),
+			obj:     configMap("app", nil),
+			want:    true,
+		},
+		{
+			name:    "namespace glob does not match",
+			include: []v1.Resource{{Name: string(v1.ConfigMap), Namespace: "team-*"}},
+			ns:      namespace("prod", nil),
+			obj:     configMap("app", nil),
+			want:    false,
+		},
+		{
+			name:    "namespace selector matches",
+			include: []v1.Resource{{Name: string(v1.ConfigMap), NamespaceSelector: team}},
+			ns:      namespace("dev", map[string]string{"team": "a"}),
+			obj:     configMap("app", nil),
+			want:    true,
+		},
+		{
+			name:    "namespace selector does not match",
+			include: []v1.Resource{{Name: string(v1.ConfigMap), NamespaceSelector: team}},
+			ns:      namespace("dev", map[string]string{"team": "b"}),
+			obj:     configMap("app", nil),
+			want:    false,
+		},
+		{
+			name:    "name regex does not match",
+			include: []v1.Resource{{Name: string(v1.ConfigMap), NameRegex: "^tmp-"}},
+			ns:      namespace("dev", nil),
+			obj:     configMap("app", nil),
+			want:    false,
+		},
+		{
+			name:    "exclude by label",
+			exclude: []v1.Resource{{Name: string(v1.ConfigMap), Selector: keep}},
+			ns:      namespace("dev", nil),
+			obj:     configMap("app", map[string]string{"keep": "true"}),
+			want:    false,
+		},
+		{
+			name:    "exclude of the whole kind",
+			exclude: []v1.Resource{{Name: string(v1.ConfigMap)}},
+			ns:      namespace("dev", nil),
+			obj:     configMap("app", nil),
+			want:    false,
+		},
+		{
+			name:    "cluster scoped object passes namespace bound includes",
+			include: []v1.Resource{{Name: string(v1.ConfigMap), Namespace: "team-*"}},
+			obj:     configMap("app", nil),
+			want:    true,
+		},
+		{
+			name:    "cluster scoped object ignores namespace bound excludes",
+			exclude: []v1.Resource{{Name: string(v1.ConfigMap), Namespace: "kube-*"}},
+			obj:     configMap("app", nil),
+			want:    true,
+		},
+		{
+			name:    "cluster scoped object is still excluded by name",
+			exclude: []v1.Resource{{Name: string(v1.ConfigMap), NameRegex: "^app$"}},
+			obj:     configMap("app", nil),
+			want:    false,
+		},
+		{
+			name:    "invalid regex leaves everything out",
+			include: []v1.Resource{{Name: string(v1.ConfigMap), NameRegex: "("}},
+			ns:      namespace("dev", nil),
+			obj:     configMap("app", nil),
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := For(cleanerWith(tt.include, tt.exclude), v1.ConfigMap)
+			if got := s.Contains(tt.ns, tt.obj); got != tt.want {
+				t.Errorf("Contains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesNamespace(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource v1.Resource
+		ns       *corev1.Namespace
+		want     bool
+	}{
+		{
+			name:     "no constraints",
+			resource: v1.Resource{Name: string(v1.ConfigMap)},
+			ns:       namespace("dev", nil),
+			want:     true,
+		},
+		{
+			name:     "exact name",
+			resource: v1.Resource{Name: string(v1.ConfigMap), Namespace: "dev"},
+			ns:       namespace("dev", nil),
+			want:     true,
+		},
+		{
+			name:     "other name",
+			resource: v1.Resource{Name: string(v1.ConfigMap), Namespace: "dev"},
+			ns:       namespace("devel", nil),
+			want:     false,
+		},
+		{
+			name:     "glob and selector both have to match",
+			resource: v1.Resource{Name: string(v1.ConfigMap), Namespace: "team-*", NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "dev"}}},
+			ns:       namespace("team-a", map[string]string{"env": "prod"}),
+			want:     false,
+		},
+		{
+			name:     "cluster scoped objects have no namespace",
+			resource: v1.Resource{Name: string(v1.ConfigMap), Namespace: "dev"},
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es, err := entries([]v1.Resource{tt.resource}, v1.ConfigMap)
+			if err != nil {
+				t.Fatalf("entries() error = %v", err)
+			}
+			if got := es[0].matchesNamespace(tt.ns); got != tt.want {
+				t.Errorf("matchesNamespace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnabledAndListed(t *testing.T) {
+	tests := []struct {
+		name        string
+		include     []v1.Resource
+		exclude     []v1.Resource
+		wantEnabled bool
+		wantListed  bool
+	}{
+		{
+			name:        "no entries",
+			wantEnabled: true,
+			wantListed:  false,
+		},
+		{
+			name:        "listed",
+			include:     []v1.Resource{{Name: string(v1.ConfigMap), Namespace: "dev"}},
+			wantEnabled: true,
+			wantListed:  true,
+		},
+		{
+			name:        "other kind listed",
+			include:     []v1.Resource{{Name: string(v1.Secret)}},
+			wantEnabled: false,
+			wantListed:  false,
+		},
+		{
+			name:        "listed and excluded",
+			include:     []v1.Resource{{Name: string(v1.ConfigMap)}},
+			exclude:     []v1.Resource{{Name: string(v1.ConfigMap)}},
+			wantEnabled: false,
+			wantListed:  false,
+		},
+		{
+			name:        "partly excluded",
+			include:     []v1.Resource{{Name: string(v1.ConfigMap)}},
+			exclude:     []v1.Resource{{Name: string(v1.ConfigMap), Namespace: "prod"}},
+			wantEnabled: true,
+			wantListed:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := For(cleanerWith(tt.include, tt.exclude), v1.ConfigMap)
+			if got := s.Enabled(); got != tt.wantEnabled {
+				t.Errorf("Enabled() = %v, want %v", got, tt.wantEnabled)
+			}
+			if got := s.Listed(); got != tt.wantListed {
+				t.Errorf("Listed() = %v, want %v", got, tt.wantListed)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package secrets
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/podspecs"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// helmReleaseType is the type Helm stores release history in.
+const helmReleaseType corev1.SecretType = "helm.sh/release.v1"
+
+// managedKeys are labels and annotations controllers put on the secrets they
+// issue or read by themselves, which no reference in the namespace shows.
+var managedKeys = []string{
+	// cert-manager Certificates
+	"cert-manager.io/certificate-name",
+	"controller.cert-manager.io/fao",
+	// Argo CD repositories, repository credentials and clusters
+	"argocd.argoproj.io/secret-type",
+	"app.kubernetes.io/managed-by",
+}
+
+// issuerKinds are the cert-manager kinds whose ACME account keys are kept in
+// secrets.
+var issuerKinds = []string{"Issuer", "ClusterIssuer"}
+
+func HandleAllUnusedSecrets(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	namespaces, err := scope.For(cleaner, v1.Secret).Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		if scope.SystemNamespace(ns.Name) {
+			continue
+		}
+		if err := handleUnusedSecretsInNamespace(ctx, c, ns, cleaner, rep); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleUnusedSecretsInNamespace(ctx context.Context, c client.Client, ns corev1.Namespace, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	s := scope.For(cleaner, v1.Secret)
+
+	referenced, serviceAccounts, err := references(ctx, c, ns.Name)
+	if err != nil {
+		return err
+	}
+
+	secretList := &corev1.SecretList{}
+	if err := c.List(ctx, secretList, s.ListOptions(ns.Name)); err != nil {
+		return err
+	}
+	for _, secret := range secretList.Items {
+		secret := secret
+		if !s.Contains(&ns, &secret) {
+			continue
+		}
+
+		reason, certain := unusedReason(secret, referenced, serviceAccounts)
+		if reason == "" {
+			if err := sweep.Recover(ctx, c, cleaner, rep, v1.Secret, &secret); err != nil {
+				errors = append(errors, err)
+			}
+			continue
+		}
+
+		// secrets may be read through controller flags or config files no
+		// reference shows, unreferenced ones are only deleted when Secret is
+		// included explicitly
+		if !certain && !s.Listed() {
+			err = sweep.Flag(ctx, c, cleaner, rep, v1.Secret, &secret, reason)
+		} else {
+			err = sweep.Delete(ctx, c, cleaner, rep, v1.Secret, &secret, reason)
+		}
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+// references returns the names of the secrets referenced in namespace by pod
+// specs, ServiceAccounts, Ingress TLS and cert-manager issuers, and the names
+// of the namespace's ServiceAccounts.
+func references(ctx context.Context, c client.Client, namespace string) (map[string]bool, map[string]bool, error) {
+	referenced := make(map[string]bool)
+	serviceAccounts := make(map[string]bool)
+
+	specs, err := podspecs.InNamespace(ctx, c, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, spec := range specs {
+		for _, name := range podspecs.Secrets(spec) {
+			referenced[name] = true
+		}
+	}
+
+	saList := &corev1.ServiceAccountList{}
+	if err := c.List(ctx, saList, &client.ListOptions{Namespace: namespace}); err != nil {
+		return nil, nil, err
+	}
+	for _, sa := range saList.Items {
+		serviceAccounts[sa.Name] = true
+		for _, ref := range sa.ImagePullSecrets {
+			referenced[ref.Name] = true
+		}
+		for _, ref := range sa.Secrets {
+			referenced[ref.Name] = true
+		}
+	}
+
+	ingresses := &networkingv1.IngressList{}
+	if err := c.List(ctx, ingresses, &client.ListOptions{Namespace: namespace}); err != nil {
+		return nil, nil, err
+	}
+	for _, ing := range ingresses.Items {
+		for _, tls := range ing.Spec.TLS {
+			if tls.SecretName != "" {
+				referenced[tls.SecretName] = true
+			}
+		}
+	}
+
+	if err := issuerKeys(ctx, c, namespace, referenced); err != nil {
+		return nil, nil, err
+	}
+
+	return referenced, serviceAccounts, nil
+}
+
+// issuerKeys adds the ACME account key secrets of the cert-manager Issuers in
+// namespace and of every ClusterIssuer to referenced. ClusterIssuers keep
+// theirs in cert-manager's own namespace, which is not known here, so their
+// names count everywhere. Clusters without cert-manager have none.
+func issuerKeys(ctx context.Context, c client.Client, namespace string, referenced map[string]bool) error {
+	for _, kind := range issuerKinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: kind + "List"})
+		opts := &client.ListOptions{}
+		if kind == "Issuer" {
+			opts.Namespace = namespace
+		}
+		if err := c.List(ctx, list, opts); err != nil {
+			if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		for _, issuer := range list.Items {
+			name, _, _ := unstructured.NestedString(issuer.Object, "spec", "acme", "privateKeySecretRef", "name")
+			if name != "" {
+				referenced[name] = true
+			}
+		}
+	}
+	return nil
+}
+
+// unusedReason applies the rules of the secret's type and returns why it is
+// unused, or "" to keep it. The second result is false when the secret is
+// only not referenced, which does not rule out readers kubeswipe cannot see.
+func unusedReason(secret corev1.Secret, referenced, serviceAccounts map[string]bool) (string, bool) {
+	switch secret.Type {
+	case corev1.SecretTypeServiceAccountToken:
+		// a token is in use for as long as the account it is bound to exists
+		account := secret.Annotations[corev1.ServiceAccountNameKey]
+		if account == "" || serviceAccounts[account] {
+			return "", false
+		}
+		return "token of deleted service account " + account, true
+	case helmReleaseType:
+		// helm reads its release history itself, only releases uninstalled
+		// with --keep-history are left behind
+		if secret.Labels["owner"] == "helm" && secret.Labels["status"] == "uninstalled" {
+			return "helm release " + secret.Labels["name"] + " was uninstalled", true
+		}
+		return "", false
+	case corev1.SecretTypeBootstrapToken:
+		return "", false
+	}
+
+	// owned and controller managed secrets are left to their controllers
+	if len(secret.OwnerReferences) > 0 || managed(secret) {
+		return "", false
+	}
+	if referenced[secret.Name] {
+		return "", false
+	}
+	return "secret is not referenced by any pod, pod template, service account, ingress or issuer", false
+}
+
+// managed reports whether secret carries one of the managedKeys.
+func managed(secret corev1.Secret) bool {
+	for _, key := range managedKeys {
+		if _, ok := secret.Labels[key]; ok {
+			return true
+		}
+		if _, ok := secret.Annotations[key]; ok {
+			return true
+		}
+	}
+	return false
+}
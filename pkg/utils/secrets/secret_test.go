@@ -0,0 +1,124 @@
+package secrets
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func secret(name string, secretType corev1.SecretType, labels, annotations map[string]string) corev1.Secret {
+	return corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "dev", Labels: labels, Annotations: annotations},
+		Type:       secretType,
+	}
+}
+
+func TestUnusedReason(t *testing.T) {
+	referenced := map[string]bool{"tls": true}
+	serviceAccounts := map[string]bool{"app": true}
+	token := func(account string) corev1.Secret {
+		return secret("token", corev1.SecretTypeServiceAccountToken, nil, map[string]string{corev1.ServiceAccountNameKey: account})
+	}
+	helm := func(status string) corev1.Secret {
+		return secret("release", helmReleaseType, map[string]string{"owner": "helm", "name": "web", "status": status}, nil)
+	}
+	owned := secret("owned", corev1.SecretTypeOpaque, nil, nil)
+	owned.OwnerReferences = []metav1.OwnerReference{{Kind: "Certificate", Name: "web"}}
+
+	tests := []struct {
+		name        string
+		secret      corev1.Secret
+		wantUnused  bool
+		wantCertain bool
+	}{
+		{name: "token of an account", secret: token("app")},
+		{name: "token of a deleted account", secret: token("gone"), wantUnused: true, wantCertain: true},
+		{name: "deployed helm release", secret: helm("deployed")},
+		{name: "uninstalled helm release", secret: helm("uninstalled"), wantUnused: true, wantCertain: true},
+		{name: "bootstrap token", secret: secret("bootstrap-token-abc", corev1.SecretTypeBootstrapToken, nil, nil)},
+		{name: "referenced", secret: secret("tls", corev1.SecretTypeTLS, nil, nil)},
+		{name: "owned", secret: owned},
+		{name: "issued by cert-manager", secret: secret("cert", corev1.SecretTypeTLS, nil, map[string]string{"cert-manager.io/certificate-name": "web"})},
+		{name: "argo cd repository", secret: secret("repo", corev1.SecretTypeOpaque, map[string]string{"argocd.argoproj.io/secret-type": "repository"}, nil)},
+		{name: "managed by a tool", secret: secret("managed", corev1.SecretTypeOpaque, map[string]string{"app.kubernetes.io/managed-by": "vault"}, nil)},
+		{name: "not referenced", secret: secret("stale", corev1.SecretTypeOpaque, nil, nil), wantUnused: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, certain := unusedReason(tt.secret, referenced, serviceAccounts)
+			if (reason != "") != tt.wantUnused || certain != tt.wantCertain {
+				t.Errorf("unusedReason() = %q, %v, want unused %v, certain %v", reason, certain, tt.wantUnused, tt.wantCertain)
+			}
+		})
+	}
+}
+
+func TestHandleUnusedSecretsInNamespace(t *testing.T) {
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}}
+	stale := secret("stale", corev1.SecretTypeOpaque, nil, nil)
+	token := secret("token", corev1.SecretTypeServiceAccountToken, nil, map[string]string{corev1.ServiceAccountNameKey: "gone"})
+	mounted := secret("mounted", corev1.SecretTypeOpaque, nil, nil)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "dev"},
+		Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+			Name:         "secret",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "mounted"}},
+		}}},
+	}
+
+	tests := []struct {
+		name    string
+		include []v1.Resource
+		want    map[string]v1.SweepAction
+	}{
+		{
+			name: "unreferenced secrets are only reported unless listed",
+			want: map[string]v1.SweepAction{"stale": v1.Flagged, "token": v1.Deleted},
+		},
+		{
+			name:    "listed",
+			include: []v1.Resource{{Name: string(v1.Secret)}},
+			want:    map[string]v1.SweepAction{"stale": v1.Deleted, "token": v1.Deleted},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithObjects(&ns, pod, stale.DeepCopy(), token.DeepCopy(), mounted.DeepCopy()).Build()
+			cleaner := v1.ResourceCleaner{}
+			cleaner.Spec.Operation = v1.CleanUp
+			cleaner.Spec.Resources.Include = tt.include
+			rep := sweep.NewReport()
+
+			if err := handleUnusedSecretsInNamespace(context.Background(), c, ns, cleaner, rep); err != nil {
+				t.Fatalf("handleUnusedSecretsInNamespace() error = %v", err)
+			}
+
+			status := v1.ResourceCleanerStatus{}
+			rep.WriteStatus(&status)
+			got := make(map[string]v1.SweepAction)
+			for _, o := range status.RecentObjects {
+				got[o.Name] = o.Action
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("recorded %v, want %v", got, tt.want)
+			}
+			for name, action := range tt.want {
+				if got[name] != action {
+					t.Errorf("%s recorded as %q, want %q", name, got[name], action)
+				}
+				exists := c.Get(context.Background(), client.ObjectKey{Namespace: "dev", Name: name}, &corev1.Secret{}) == nil
+				if exists != (action != v1.Deleted) {
+					t.Errorf("%s exists = %v after %q", name, exists, action)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package serviceaccounts
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/podspecs"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// defaultAccount is created in every namespace and recreated when deleted.
+const defaultAccount = "default"
+
+func HandleAllUnusedServiceAccounts(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	namespaces, err := scope.For(cleaner, v1.ServiceAccount).Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	bound, err := boundAccounts(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		if scope.SystemNamespace(ns.Name) {
+			continue
+		}
+		if err := handleUnusedServiceAccountsInNamespace(ctx, c, ns, cleaner, rep, bound); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleUnusedServiceAccountsInNamespace(ctx context.Context, c client.Client, ns corev1.Namespace, cleaner v1.ResourceCleaner, rep *sweep.Report, bound map[client.ObjectKey]bool) error {
+	var errors []error
+	s := scope.For(cleaner, v1.ServiceAccount)
+
+	used, err := usedAccounts(ctx, c, ns.Name)
+	if err != nil {
+		return err
+	}
+	tokens, err := legacyTokens(ctx, c, ns.Name)
+	if err != nil {
+		return err
+	}
+
+	saList := &corev1.ServiceAccountList{}
+	if err := c.List(ctx, saList, s.ListOptions(ns.Name)); err != nil {
+		return err
+	}
+	for _, sa := range saList.Items {
+		sa := sa
+		if !s.Contains(&ns, &sa) {
+			continue
+		}
+
+		// the default account is recreated on deletion and owned accounts
+		// belong to their controllers
+		if sa.Name == defaultAccount || len(sa.OwnerReferences) > 0 ||
+			used[sa.Name] || bound[client.ObjectKeyFromObject(&sa)] {
+			if err := sweep.Recover(ctx, c, cleaner, rep, v1.ServiceAccount, &sa); err != nil {
+				errors = append(errors, err)
+			}
+			continue
+		}
+
+		var dependents []sweep.Dependent
+		for _, token := range tokens[sa.Name] {
+			dependents = append(dependents, sweep.Dependent{
+				Kind:   v1.Secret,
+				Object: token,
+				Reason: "token of unused service account " + sa.Name,
+			})
+		}
+		reason := "service account is not used by any pod or pod template and is not bound to any role"
+		if err := sweep.DeleteWith(ctx, c, cleaner, rep, v1.ServiceAccount, &sa, reason, dependents); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+// usedAccounts returns the names of the ServiceAccounts in namespace that pods
+// or pod templates run as.
+func usedAccounts(ctx context.Context, c client.Client, namespace string) (map[string]bool, error) {
+	used := make(map[string]bool)
+
+	specs, err := podspecs.InNamespace(ctx, c, namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, spec := range specs {
+		used[podspecs.ServiceAccount(spec)] = true
+	}
+
+	return used, nil
+}
+
+// boundAccounts returns the ServiceAccounts that are subjects of any
+// RoleBinding or ClusterRoleBinding. RoleBindings may bind accounts of other
+// namespaces, so they are listed across the cluster too.
+func boundAccounts(ctx context.Context, c client.Client) (map[client.ObjectKey]bool, error) {
+	subjects := make(map[client.ObjectKey]bool)
+
+	clusterBindings := &rbacv1.ClusterRoleBindingList{}
+	if err := c.List(ctx, clusterBindings); err != nil {
+		return nil, err
+	}
+	for _, binding := range clusterBindings.Items {
+		addAccountSubjects(subjects, binding.Subjects, "")
+	}
+
+	bindings := &rbacv1.RoleBindingList{}
+	if err := c.List(ctx, bindings); err != nil {
+		return nil, err
+	}
+	for _, binding := range bindings.Items {
+		addAccountSubjects(subjects, binding.Subjects, binding.Namespace)
+	}
+	return subjects, nil
+}
+
+// addAccountSubjects adds the ServiceAccount subjects in list to subjects.
+// Subjects without a namespace live in the namespace of their RoleBinding.
+func addAccountSubjects(subjects map[client.ObjectKey]bool, list []rbacv1.Subject, namespace string) {
+	for _, subject := range list {
+		if subject.Kind != rbacv1.ServiceAccountKind {
+			continue
+		}
+		key := client.ObjectKey{Namespace: subject.Namespace, Name: subject.Name}
+		if key.Namespace == "" {
+			key.Namespace = namespace
+		}
+		subjects[key] = true
+	}
+}
+
+// legacyTokens returns the long-lived token Secrets in namespace by the name
+// of the ServiceAccount they belong to.
+func legacyTokens(ctx context.Context, c client.Client, namespace string) (map[string][]*corev1.Secret, error) {
+	secretList := &corev1.SecretList{}
+	if err := c.List(ctx, secretList, &client.ListOptions{Namespace: namespace}); err != nil {
+		return nil, err
+	}
+	tokens := make(map[string][]*corev1.Secret)
+	for i := range secretList.Items {
+		secret := &secretList.Items[i]
+		if secret.Type != corev1.SecretTypeServiceAccountToken {
+			continue
+		}
+		account := secret.Annotations[corev1.ServiceAccountNameKey]
+		if account != "" {
+			tokens[account] = append(tokens[account], secret)
+		}
+	}
+	return tokens, nil
+}
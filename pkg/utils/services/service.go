@@ -8,7 +8,8 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	v1 "kubefit.com/kubeswipe/api/v1"
 	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
-	filesUtil "kubefit.com/kubeswipe/pkg/utils/files"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -18,139 +19,164 @@ type Service struct {
 	Namespace string
 }
 
-func deleteUnusedServicesInNamespace(ctx context.Context, c client.Client, namespace string, cleaner v1.ResourceCleaner) ([]Service, error) {
-	var errors []error
-	endpointsList := corev1.EndpointsList{}
-	logger := log.FromContext(ctx)
-	if err := c.List(ctx, &endpointsList, &client.ListOptions{Namespace: namespace}); err != nil {
-		return nil, err
+// Idle reports whether nothing backs service, along with the reason.
+// ExternalName services never have endpoints and are not considered, nor
+// are services without a selector, whose endpoints are managed by hand or by
+// another controller, if they have any.
+func Idle(ctx context.Context, c client.Client, service *corev1.Service) (bool, string, error) {
+	if service.Spec.Type == corev1.ServiceTypeExternalName || len(service.Spec.Selector) == 0 {
+		return false, "", nil
 	}
 
-	var unusedServices []Service
-	for _, endpoints := range endpointsList.Items {
-		if len(endpoints.Subsets) == 0 {
-			logger.Info("unused service found in namespace: " + namespace + " with name: " + endpoints.Name + " and namespace: " + endpoints.Namespace)
-			service := corev1.Service{}
-			err := c.Get(ctx, types.NamespacedName{Name: endpoints.Name, Namespace: endpoints.Namespace}, &service)
-			if err != nil {
-				if apierrors.IsNotFound(err) {
-					logger.Info("service " + service.Name + " not found")
-				} else {
-					errors = append(errors, err)
-				}
-			}
+	endpoints := corev1.Endpoints{}
+	err := c.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, &endpoints)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, "service has no endpoints object", nil
+		}
+		return false, "", err
+	}
+	if len(endpoints.Subsets) == 0 {
+		return true, "service has no endpoints", nil
+	}
+	return false, "", nil
+}
 
-			if cleaner.Spec.Resources.Backup {
-				err := filesUtil.CreateFile(service, "services", service.Name, cleaner)
-				if err != nil {
-					errors = append(errors, err)
-				}
-			}
+type unusedService struct {
+	service corev1.Service
+	reason  string
+}
 
-			err = c.Delete(ctx, &service)
-			if err != nil {
-				errors = append(errors, err)
-			}
-			unusedServices = append(unusedServices, Service{
-				Name:      endpoints.Name,
-				Namespace: endpoints.Namespace,
-			})
+// findUnusedServicesInNamespace returns the unused services in scope, and
+// the ones in use.
+func findUnusedServicesInNamespace(ctx context.Context, c client.Client, ns corev1.Namespace, cleaner v1.ResourceCleaner) ([]unusedService, []corev1.Service, error) {
+	var errors []error
+	s := scope.For(cleaner, v1.Service)
+	logger := log.FromContext(ctx)
+
+	serviceList := corev1.ServiceList{}
+	if err := c.List(ctx, &serviceList, s.ListOptions(ns.Name)); err != nil {
+		return nil, nil, err
+	}
+
+	var unusedServices []unusedService
+	var usedServices []corev1.Service
+	for _, service := range serviceList.Items {
+		if !s.Contains(&ns, &service) {
+			continue
 		}
+		idle, reason, err := Idle(ctx, c, &service)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		if !idle {
+			usedServices = append(usedServices, service)
+			continue
+		}
+		logger.Info("unused service found in namespace: " + ns.Name + " with name: " + service.Name)
+		unusedServices = append(unusedServices, unusedService{service: service, reason: reason})
 	}
 
 	if len(errors) > 0 {
-		errorsUtil.AggregateErrors(errors)
+		return unusedServices, usedServices, errorsUtil.AggregateErrors(errors)
 	}
 
-	return unusedServices, nil
+	return unusedServices, usedServices, nil
 }
 
-func getUnusedServicesInNamespace(ctx context.Context, c client.Client, namespace string, cleaner v1.ResourceCleaner) ([]Service, error) {
+func deleteUnusedServicesInNamespace(ctx context.Context, c client.Client, ns corev1.Namespace, cleaner v1.ResourceCleaner, rep *sweep.Report) ([]Service, error) {
 	var errors []error
-	endpointsList := corev1.EndpointsList{}
-	logger := log.FromContext(ctx)
-	if err := c.List(ctx, &endpointsList, &client.ListOptions{Namespace: namespace}); err != nil {
-		return nil, err
+	unused, used, err := findUnusedServicesInNamespace(ctx, c, ns, cleaner)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	for _, service := range used {
+		if err := sweep.Recover(ctx, c, cleaner, rep, v1.Service, &service); err != nil {
+			errors = append(errors, err)
+		}
 	}
 
 	var unusedServices []Service
-	for _, endpoints := range endpointsList.Items {
-		if len(endpoints.Subsets) == 0 {
-			logger.Info("unused service found in namespace: " + namespace + " with name: " + endpoints.Name + " and namespace: " + endpoints.Namespace)
-			service := corev1.Service{}
-			err := c.Get(ctx, types.NamespacedName{Name: endpoints.Name, Namespace: endpoints.Namespace}, &service)
-			if err != nil {
-				if apierrors.IsNotFound(err) {
-					logger.Info("service " + service.Name + " not found")
-				} else {
-					errors = append(errors, err)
-				}
-			}
-			unusedServices = append(unusedServices, Service{
-				Name:      endpoints.Name,
-				Namespace: endpoints.Namespace,
-			})
+	for _, u := range unused {
+		if err := sweep.Delete(ctx, c, cleaner, rep, v1.Service, &u.service, u.reason); err != nil {
+			errors = append(errors, err)
 		}
+		unusedServices = append(unusedServices, Service{
+			Name:      u.service.Name,
+			Namespace: u.service.Namespace,
+		})
 	}
 
 	if len(errors) > 0 {
-		errorsUtil.AggregateErrors(errors)
+		return unusedServices, errorsUtil.AggregateErrors(errors)
 	}
 
 	return unusedServices, nil
 }
 
+func getUnusedServicesInNamespace(ctx context.Context, c client.Client, ns corev1.Namespace, cleaner v1.ResourceCleaner) ([]Service, error) {
+	unused, _, err := findUnusedServicesInNamespace(ctx, c, ns, cleaner)
+
+	var unusedServices []Service
+	for _, u := range unused {
+		unusedServices = append(unusedServices, Service{
+			Name:      u.service.Name,
+			Namespace: u.service.Namespace,
+		})
+	}
+
+	return unusedServices, err
+}
+
 func GetAllUnusedServices(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner) ([]Service, error) {
 	var errors []error
-	namespaces := &corev1.NamespaceList{}
-	if err := c.List(context.TODO(), namespaces); err != nil {
+	namespaces, err := scope.For(cleaner, v1.Service).Namespaces(ctx, c)
+	if err != nil {
 		return nil, err
 	}
 
 	var unusedServices []Service
-	for _, ns := range namespaces.Items {
-		nsServices, err := getUnusedServicesInNamespace(ctx, c, ns.Name, cleaner)
+	for _, ns := range namespaces {
+		nsServices, err := getUnusedServicesInNamespace(ctx, c, ns, cleaner)
 		if err != nil {
-			if len(errors) > 0 {
-				errorsUtil.AggregateErrors(errors)
-			}
+			errors = append(errors, err)
 		}
 		unusedServices = append(unusedServices, nsServices...)
 	}
 
 	if len(errors) > 0 {
-		errorsUtil.AggregateErrors(errors)
+		return unusedServices, errorsUtil.AggregateErrors(errors)
 	}
 
 	return unusedServices, nil
 }
 
-func HandleAllUnusedServices(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner) error {
+func HandleAllUnusedServices(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
 	var errors []error
-	namespaces := &corev1.NamespaceList{}
-	if err := c.List(context.TODO(), namespaces); err != nil {
+	namespaces, err := scope.For(cleaner, v1.Service).Namespaces(ctx, c)
+	if err != nil {
 		return err
 	}
 
 	// var unusedServices []Service
-	for _, ns := range namespaces.Items {
-		if string(cleaner.Spec.Operation) == string(v1.CleanUp) {
-			_, err := deleteUnusedServicesInNamespace(ctx, c, ns.Name, cleaner)
-			if err != nil {
-				errors = append(errors, err)
-			}
+	for _, ns := range namespaces {
+		_, err := deleteUnusedServicesInNamespace(ctx, c, ns, cleaner, rep)
+		if err != nil {
+			errors = append(errors, err)
 		}
 		// unusedServices = append(unusedServices, nsServices...)
 	}
 
 	if len(errors) > 0 {
-		errorsUtil.AggregateErrors(errors)
+		return errorsUtil.AggregateErrors(errors)
 	}
 
 	return nil
 }
 
-func DeleteUnunsedServices(ctx context.Context, c client.Client, services []Service, cleaner v1.ResourceCleaner) error {
+func DeleteUnunsedServices(ctx context.Context, c client.Client, services []Service, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
 	logger := log.FromContext(ctx)
 	var errors []error
 	for _, svc := range services {
@@ -158,28 +184,22 @@ func DeleteUnunsedServices(ctx context.Context, c client.Client, services []Serv
 		err := c.Get(ctx, types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace}, &service)
 		if err != nil {
 			if apierrors.IsNotFound(err) {
-				logger.Info("service " + service.Name + " not found")
+				logger.Info("service " + svc.Name + " not found")
 			} else {
 				errors = append(errors, err)
 			}
+			continue
 		}
 
-		if cleaner.Spec.Resources.Backup {
-			err := filesUtil.CreateFile(service, "services", service.Name, cleaner)
-			if err != nil {
-				errors = append(errors, err)
-			}
-		}
-
-		err = c.Delete(ctx, &service)
-		if err != nil {
+		if err := sweep.Delete(ctx, c, cleaner, rep, v1.Service, &service, "service has no endpoints"); err != nil {
 			errors = append(errors, err)
-		}
-		if len(errors) > 0 {
-			errorsUtil.AggregateErrors(errors)
+			continue
 		}
 		logger.Info("succesfully cleaned " + service.Name + " service")
 
 	}
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
 	return nil
 }
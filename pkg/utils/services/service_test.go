@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func service(name string, spec corev1.ServiceSpec) *corev1.Service {
+	return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "dev"}, Spec: spec}
+}
+
+func endpoints(name string, subsets ...corev1.EndpointSubset) *corev1.Endpoints {
+	return &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "dev"}, Subsets: subsets}
+}
+
+func TestIdle(t *testing.T) {
+	app := corev1.ServiceSpec{Selector: map[string]string{"app": "web"}}
+	ready := corev1.EndpointSubset{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}
+
+	tests := []struct {
+		name      string
+		service   *corev1.Service
+		endpoints *corev1.Endpoints
+		want      bool
+	}{
+		{
+			name:      "backed",
+			service:   service("web", app),
+			endpoints: endpoints("web", ready),
+		},
+		{
+			name:      "no endpoints",
+			service:   service("web", app),
+			endpoints: endpoints("web"),
+			want:      true,
+		},
+		{
+			name:    "no endpoints object",
+			service: service("web", app),
+			want:    true,
+		},
+		{
+			name:    "external name",
+			service: service("web", corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "example.com"}),
+		},
+		{
+			name:    "no selector and no endpoints object",
+			service: service("web", corev1.ServiceSpec{}),
+		},
+		{
+			name:      "headless without selector",
+			service:   service("web", corev1.ServiceSpec{ClusterIP: corev1.ClusterIPNone}),
+			endpoints: endpoints("web"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := []client.Object{tt.service}
+			if tt.endpoints != nil {
+				objs = append(objs, tt.endpoints)
+			}
+			c := fake.NewClientBuilder().WithObjects(objs...).Build()
+
+			got, reason, err := Idle(context.Background(), c, tt.service)
+			if err != nil {
+				t.Fatalf("Idle() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Idle() = %v, %q, want %v", got, reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteUnusedServicesInNamespace(t *testing.T) {
+	app := corev1.ServiceSpec{Selector: map[string]string{"app": "web"}}
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}}
+	c := fake.NewClientBuilder().WithObjects(
+		&ns,
+		service("idle", app),
+		service("manual", corev1.ServiceSpec{}),
+		service("web", app), endpoints("web", corev1.EndpointSubset{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}),
+	).Build()
+
+	cleaner := v1.ResourceCleaner{}
+	cleaner.Spec.Operation = v1.CleanUp
+	if _, err := deleteUnusedServicesInNamespace(context.Background(), c, ns, cleaner, sweep.NewReport()); err != nil {
+		t.Fatalf("deleteUnusedServicesInNamespace() error = %v", err)
+	}
+
+	for name, wantDeleted := range map[string]bool{"idle": true, "manual": false, "web": false} {
+		err := c.Get(context.Background(), client.ObjectKey{Namespace: "dev", Name: name}, &corev1.Service{})
+		if deleted := apierrors.IsNotFound(err); deleted != wantDeleted {
+			t.Errorf("service %s deleted = %v, want %v (%v)", name, deleted, wantDeleted, err)
+		}
+	}
+}
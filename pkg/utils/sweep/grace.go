@@ -0,0 +1,151 @@
+package sweep
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type markerKey struct{}
+
+// WithMarker returns ctx for a handler that sweeps objects other handlers own
+// as well, such as rules and orphans. The candidate marks it sets, checks and
+// removes are kept under their own annotation, v1.CandidateSinceKey followed
+// by "-" and name, so they do not reset the marks of the kind's handler.
+func WithMarker(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, markerKey{}, name)
+}
+
+// candidateKey returns the annotation that holds the candidate mark for the
+// handler running with ctx.
+func candidateKey(ctx context.Context) string {
+	if name, _ := ctx.Value(markerKey{}).(string); name != "" {
+		return v1.CandidateSinceKey + "-" + name
+	}
+	return v1.CandidateSinceKey
+}
+
+// CandidateSince returns when obj was first found unused by the handler
+// running with ctx, if it was marked.
+func CandidateSince(ctx context.Context, obj client.Object) (time.Time, bool) {
+	value, ok := obj.GetAnnotations()[candidateKey(ctx)]
+	if !ok {
+		return time.Time{}, false
+	}
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return since, true
+}
+
+// WithMinGrace returns cleaner with its grace period raised to at least d,
+// for handlers whose objects have to stay unused for a minimum time before
+// they are swept.
+func WithMinGrace(cleaner v1.ResourceCleaner, d time.Duration) v1.ResourceCleaner {
+	if cleaner.Spec.GracePeriod == nil || cleaner.Spec.GracePeriod.Duration < d {
+		cleaner.Spec.GracePeriod = &metav1.Duration{Duration: d}
+	}
+	return cleaner
+}
+
+// graceElapsed marks obj as a candidate on first sight and reports whether it
+// has been one for the cleaner's whole grace period. Objects that are not due
+// yet are recorded as marked. A dry run marks nothing and plans only what a
+// cleanup would delete now, so they are recorded as flagged instead.
+func graceElapsed(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *Report, kind v1.ResourceNames, obj client.Object, reason string) (bool, error) {
+	if cleaner.Spec.GracePeriod == nil || cleaner.Spec.GracePeriod.Duration <= 0 {
+		return true, nil
+	}
+	grace := cleaner.Spec.GracePeriod.Duration
+
+	since, marked := CandidateSince(ctx, obj)
+	if marked && time.Since(since) >= grace {
+		return true, nil
+	}
+
+	if !marked {
+		since = time.Now()
+	}
+	pending := reason + ", deletion after " + since.Add(grace).UTC().Format(time.RFC3339)
+	if DryRun(cleaner) {
+		rep.Record(kind, obj, v1.Flagged, pending)
+		return false, nil
+	}
+
+	if !marked {
+		if err := setCandidateSince(ctx, c, obj, since.UTC().Format(time.RFC3339)); err != nil {
+			rep.Record(kind, obj, v1.Failed, "marking as candidate: "+err.Error())
+			return false, err
+		}
+	}
+	rep.Record(kind, obj, v1.Marked, pending)
+	return false, nil
+}
+
+// Recover removes the candidate mark from obj once a handler finds it in use
+// again. Handlers call it for every object in scope they leave alone.
+func Recover(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *Report, kind v1.ResourceNames, obj client.Object) error {
+	if _, marked := CandidateSince(ctx, obj); !marked || DryRun(cleaner) {
+		return nil
+	}
+	if err := setCandidateSince(ctx, c, obj, ""); err != nil {
+		rep.Record(kind, obj, v1.Failed, "removing candidate mark: "+err.Error())
+		return err
+	}
+	rep.Record(kind, obj, v1.Recovered, "in use again")
+	return nil
+}
+
+// PruneMarkers removes from obj the candidate marks of handlers whose marker
+// name starts with prefix but is not in keep. Handlers whose marker names
+// change with their configuration, such as rules, call it so the marks of
+// earlier configurations do not pile up. A dry run leaves them in place.
+func PruneMarkers(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *Report, kind v1.ResourceNames, obj client.Object, prefix string, keep map[string]bool) error {
+	if DryRun(cleaner) {
+		return nil
+	}
+	var stale []string
+	for key := range obj.GetAnnotations() {
+		name, ok := strings.CutPrefix(key, v1.CandidateSinceKey+"-")
+		if ok && strings.HasPrefix(name, prefix) && !keep[name] {
+			stale = append(stale, key)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	annotations := obj.GetAnnotations()
+	for _, key := range stale {
+		delete(annotations, key)
+	}
+	obj.SetAnnotations(annotations)
+	if err := c.Patch(ctx, obj, patch); err != nil {
+		rep.Record(kind, obj, v1.Failed, "removing stale candidate marks: "+err.Error())
+		return err
+	}
+	return nil
+}
+
+// setCandidateSince patches the candidate mark on obj, removing it when value
+// is empty.
+func setCandidateSince(ctx context.Context, c client.Client, obj client.Object, value string) error {
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if value == "" {
+		delete(annotations, candidateKey(ctx))
+	} else {
+		annotations[candidateKey(ctx)] = value
+	}
+	obj.SetAnnotations(annotations)
+	return c.Patch(ctx, obj, patch)
+}
@@ -0,0 +1,109 @@
+package sweep
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Report collects what a single cleaner run found and did, so the
+// controller can publish it on the ResourceCleaner status.
+type Report struct {
+	counts  map[v1.ResourceNames]*v1.ResourceCount
+	kinds   []v1.ResourceNames
+	objects []v1.SweptObject
+	plan    []v1.SweptObject
+}
+
+func NewReport() *Report {
+	return &Report{counts: make(map[v1.ResourceNames]*v1.ResourceCount)}
+}
+
+func (r *Report) count(kind v1.ResourceNames) *v1.ResourceCount {
+	rc, ok := r.counts[kind]
+	if !ok {
+		rc = &v1.ResourceCount{Kind: kind}
+		r.counts[kind] = rc
+		r.kinds = append(r.kinds, kind)
+	}
+	return rc
+}
+
+// BackedUp counts a backup written for an object of the given kind.
+func (r *Report) BackedUp(kind v1.ResourceNames) {
+	r.count(kind).BackedUp++
+}
+
+// Record stores the outcome of handling obj and updates the counters of its kind.
+func (r *Report) Record(kind v1.ResourceNames, obj client.Object, action v1.SweepAction, reason string) {
+	rc := r.count(kind)
+	if action != v1.Recovered {
+		rc.Found++
+	}
+	switch action {
+	case v1.Deleted:
+		rc.Deleted++
+	case v1.Failed:
+		rc.Failed++
+	case v1.Planned:
+		rc.Planned++
+	case v1.Protected:
+		rc.Protected++
+	case v1.Marked:
+		rc.Marked++
+	case v1.Recovered:
+		rc.Recovered++
+	case v1.Flagged:
+		rc.Flagged++
+	case v1.Suspended:
+		rc.Suspended++
+	}
+
+	swept := v1.SweptObject{
+		Kind:      kind,
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+		Action:    action,
+		Reason:    reason,
+		Time:      metav1.Now(),
+	}
+	if action == v1.Planned {
+		r.plan = append(r.plan, swept)
+		return
+	}
+	r.objects = append(r.objects, swept)
+}
+
+// Failed returns the number of objects that could not be handled.
+func (r *Report) Failed() int32 {
+	var failed int32
+	for _, rc := range r.counts {
+		failed += rc.Failed
+	}
+	return failed
+}
+
+// WriteStatus replaces the counters and the plan on status with the ones of
+// this run and prepends the recorded objects to the recent objects, keeping
+// at most v1.MaxRecentObjects of them.
+func (r *Report) WriteStatus(status *v1.ResourceCleanerStatus) {
+	status.Resources = make([]v1.ResourceCount, 0, len(r.kinds))
+	for _, kind := range r.kinds {
+		status.Resources = append(status.Resources, *r.counts[kind])
+	}
+
+	recent := make([]v1.SweptObject, 0, len(r.objects)+len(status.RecentObjects))
+	for i := len(r.objects) - 1; i >= 0; i-- {
+		recent = append(recent, r.objects[i])
+	}
+	recent = append(recent, status.RecentObjects...)
+	if len(recent) > v1.MaxRecentObjects {
+		recent = recent[:v1.MaxRecentObjects]
+	}
+	status.RecentObjects = recent
+
+	status.Plan = r.plan
+	if len(status.Plan) > v1.MaxPlanObjects {
+		status.Plan = status.Plan[:v1.MaxPlanObjects]
+	}
+}
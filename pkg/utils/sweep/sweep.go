@@ -0,0 +1,202 @@
+package sweep
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	filesUtil "kubefit.com/kubeswipe/pkg/utils/files"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// BackupDir is the directory under the backup root that holds objects of kind.
+func BackupDir(kind v1.ResourceNames) string {
+	return strings.ToLower(string(kind)) + "s"
+}
+
+// backupDir is the directory the backup of obj goes to. Namespaced objects
+// get a directory per namespace, so objects of the same name in different
+// namespaces do not overwrite each other.
+func backupDir(kind v1.ResourceNames, obj client.Object) string {
+	if obj.GetNamespace() == "" {
+		return BackupDir(kind)
+	}
+	return BackupDir(kind) + "/" + obj.GetNamespace()
+}
+
+// Protected reports whether obj, or the namespace it lives in, is marked
+// with v1.ProtectKey.
+func Protected(ctx context.Context, c client.Client, obj client.Object) (bool, error) {
+	if hasProtectKey(obj) {
+		return true, nil
+	}
+	if obj.GetNamespace() == "" {
+		return false, nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKey{Name: obj.GetNamespace()}, ns); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return hasProtectKey(ns), nil
+}
+
+func hasProtectKey(obj client.Object) bool {
+	return obj.GetAnnotations()[v1.ProtectKey] == "true" || obj.GetLabels()[v1.ProtectKey] == "true"
+}
+
+// guard records protected objects in rep and tells the caller whether it may
+// go on touching obj.
+func guard(ctx context.Context, c client.Client, rep *Report, kind v1.ResourceNames, obj client.Object) (bool, error) {
+	protected, err := Protected(ctx, c, obj)
+	if err != nil {
+		rep.Record(kind, obj, v1.Failed, "checking protection: "+err.Error())
+		return false, err
+	}
+	if protected {
+		rep.Record(kind, obj, v1.Protected, v1.ProtectKey+" is set")
+		return false, nil
+	}
+	return true, nil
+}
+
+// Backup writes obj to the backup directory when the cleaner asks for backups.
+func Backup(cleaner v1.ResourceCleaner, rep *Report, kind v1.ResourceNames, obj client.Object) error {
+	if !cleaner.Spec.Resources.Backup {
+		return nil
+	}
+	if err := filesUtil.CreateFile(obj, obj.GetName(), backupDir(kind, obj), cleaner); err != nil {
+		return err
+	}
+	rep.BackedUp(kind)
+	return nil
+}
+
+// DryRun reports whether the cleaner may only look at the cluster. Only the
+// CLEANUP operation deletes anything.
+func DryRun(cleaner v1.ResourceCleaner) bool {
+	return cleaner.Spec.Operation != v1.CleanUp
+}
+
+// Due runs the checks that come before any change to an unused object:
+// protection, the grace period and dry run. When obj has to be left alone for
+// now the outcome is recorded in rep and false is returned; otherwise the
+// caller acts on obj and records the result itself.
+func Due(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *Report, kind v1.ResourceNames, obj client.Object, reason string) (bool, error) {
+	return DueFor(ctx, c, cleaner, rep, kind, obj, kind, obj, reason)
+}
+
+// DueFor is Due for acting on obj because of marked, the object that was
+// found unused and carries the grace mark, such as the owner of an idle pod.
+// Protection and the grace period are checked on marked, while the plan of
+// a dry run names obj, which is what a cleanup would change.
+func DueFor(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *Report, markedKind v1.ResourceNames, marked client.Object, kind v1.ResourceNames, obj client.Object, reason string) (bool, error) {
+	if ok, err := guard(ctx, c, rep, markedKind, marked); !ok {
+		return false, err
+	}
+
+	if due, err := graceElapsed(ctx, c, cleaner, rep, markedKind, marked, reason); !due {
+		return false, err
+	}
+
+	if DryRun(cleaner) {
+		rep.Record(kind, obj, v1.Planned, reason)
+		return false, nil
+	}
+	return true, nil
+}
+
+// Delete backs obj up, deletes it and records the outcome in rep. An object
+// that is already gone is not an error. If the backup fails the object is
+// left in place. Protected objects are never touched, objects within the
+// cleaner's grace period are only marked, and on a dry run the object is
+// only added to the plan.
+func Delete(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *Report, kind v1.ResourceNames, obj client.Object, reason string, opts ...client.DeleteOption) error {
+	if due, err := Due(ctx, c, cleaner, rep, kind, obj, reason); !due {
+		return err
+	}
+	return Remove(ctx, c, cleaner, rep, kind, obj, reason, opts...)
+}
+
+// Flag records obj as idle without touching it, for handlers whose objects
+// are only reported unless the cleaner asks for more. Only protection is
+// checked: nothing is going to be deleted, so obj is neither marked nor
+// added to the plan.
+func Flag(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *Report, kind v1.ResourceNames, obj client.Object, reason string) error {
+	if ok, err := guard(ctx, c, rep, kind, obj); !ok {
+		return err
+	}
+	rep.Record(kind, obj, v1.Flagged, reason)
+	return nil
+}
+
+// Dependent is an object that is swept together with the object it belongs
+// to, such as the token Secrets of a ServiceAccount.
+type Dependent struct {
+	Kind   v1.ResourceNames
+	Object client.Object
+	Reason string
+}
+
+// DeleteWith deletes obj like Delete does, along with its dependents. The
+// grace period and dry run are decided by obj alone, so the whole unit is
+// marked, planned or deleted at once. Protected dependents are left in
+// place; the others are deleted before obj.
+func DeleteWith(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *Report, kind v1.ResourceNames, obj client.Object, reason string, dependents []Dependent) error {
+	if ok, err := guard(ctx, c, rep, kind, obj); !ok {
+		return err
+	}
+	if due, err := graceElapsed(ctx, c, cleaner, rep, kind, obj, reason); !due {
+		return err
+	}
+
+	if DryRun(cleaner) {
+		rep.Record(kind, obj, v1.Planned, reason)
+		for _, d := range dependents {
+			rep.Record(d.Kind, d.Object, v1.Planned, d.Reason)
+		}
+		return nil
+	}
+
+	for _, d := range dependents {
+		if ok, err := guard(ctx, c, rep, d.Kind, d.Object); !ok {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err := Remove(ctx, c, cleaner, rep, d.Kind, d.Object, d.Reason); err != nil {
+			return err
+		}
+	}
+	return Remove(ctx, c, cleaner, rep, kind, obj, reason)
+}
+
+// Remove backs obj up, deletes it and records the outcome, once the checks
+// in Due have passed.
+func Remove(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *Report, kind v1.ResourceNames, obj client.Object, reason string, opts ...client.DeleteOption) error {
+	logger := log.FromContext(ctx)
+
+	if err := Backup(cleaner, rep, kind, obj); err != nil {
+		rep.Record(kind, obj, v1.Failed, "backup failed: "+err.Error())
+		return err
+	}
+
+	if err := c.Delete(ctx, obj, opts...); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		rep.Record(kind, obj, v1.Failed, err.Error())
+		return err
+	}
+
+	logger.Info("deleted "+string(kind), "name", obj.GetName(), "namespace", obj.GetNamespace(), "reason", reason)
+	rep.Record(kind, obj, v1.Deleted, reason)
+	return nil
+}
@@ -0,0 +1,354 @@
+package sweep
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func cleaner(operation v1.OperationName, grace time.Duration) v1.ResourceCleaner {
+	c := v1.ResourceCleaner{}
+	c.Spec.Operation = operation
+	if grace != 0 {
+		c.Spec.GracePeriod = &metav1.Duration{Duration: grace}
+	}
+	return c
+}
+
+func configMap(annotations map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "dev", Annotations: annotations}}
+}
+
+// actions returns the actions rep recorded, in order, plan included.
+func actions(rep *Report) []v1.SweepAction {
+	var out []v1.SweepAction
+	for _, o := range rep.objects {
+		out = append(out, o.Action)
+	}
+	for _, o := range rep.plan {
+		out = append(out, o.Action)
+	}
+	return out
+}
+
+func sameActions(a, b []v1.SweepAction) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDue(t *testing.T) {
+	longAgo := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	recently := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name        string
+		cleaner     v1.ResourceCleaner
+		annotations map[string]string
+		nsProtected bool
+		want        bool
+		wantActions []v1.SweepAction
+		wantMarked  bool
+	}{
+		{
+			name:    "no grace period",
+			cleaner: cleaner(v1.CleanUp, 0),
+			want:    true,
+		},
+		{
+			name:        "dry run without grace period",
+			cleaner:     cleaner("", 0),
+			wantActions: []v1.SweepAction{v1.Planned},
+		},
+		{
+			name:        "protected",
+			cleaner:     cleaner(v1.CleanUp, 0),
+			annotations: map[string]string{v1.ProtectKey: "true"},
+			wantActions: []v1.SweepAction{v1.Protected},
+		},
+		{
+			name:        "protected namespace",
+			cleaner:     cleaner(v1.CleanUp, 0),
+			nsProtected: true,
+			wantActions: []v1.SweepAction{v1.Protected},
+		},
+		{
+			name:        "first sight within grace period",
+			cleaner:     cleaner(v1.CleanUp, 24*time.Hour),
+			wantActions: []v1.SweepAction{v1.Marked},
+			wantMarked:  true,
+		},
+		{
+			name:        "marked within grace period",
+			cleaner:     cleaner(v1.CleanUp, 24*time.Hour),
+			annotations: map[string]string{v1.CandidateSinceKey: recently},
+			wantActions: []v1.SweepAction{v1.Marked},
+			wantMarked:  true,
+		},
+		{
+			name:        "grace period elapsed",
+			cleaner:     cleaner(v1.CleanUp, 24*time.Hour),
+			annotations: map[string]string{v1.CandidateSinceKey: longAgo},
+			want:        true,
+			wantMarked:  true,
+		},
+		{
+			name:        "invalid mark starts over",
+			cleaner:     cleaner(v1.CleanUp, 24*time.Hour),
+			annotations: map[string]string{v1.CandidateSinceKey: "yesterday"},
+			wantActions: []v1.SweepAction{v1.Marked},
+			wantMarked:  true,
+		},
+		{
+			name:        "dry run within grace period is neither marked nor planned",
+			cleaner:     cleaner("", 24*time.Hour),
+			wantActions: []v1.SweepAction{v1.Flagged},
+		},
+		{
+			name:        "dry run after grace period",
+			cleaner:     cleaner("", 24*time.Hour),
+			annotations: map[string]string{v1.CandidateSinceKey: longAgo},
+			wantActions: []v1.SweepAction{v1.Planned},
+			wantMarked:  true,
+		},
+		{
+			name:        "raised grace period",
+			cleaner:     WithMinGrace(cleaner(v1.CleanUp, time.Minute), 24*time.Hour),
+			annotations: map[string]string{v1.CandidateSinceKey: recently},
+			wantActions: []v1.SweepAction{v1.Marked},
+			wantMarked:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}}
+			if tt.nsProtected {
+				ns.Annotations = map[string]string{v1.ProtectKey: "true"}
+			}
+			obj := configMap(tt.annotations)
+			c := fake.NewClientBuilder().WithObjects(ns, obj).Build()
+			rep := NewReport()
+
+			got, err := Due(ctx, c, tt.cleaner, rep, v1.ConfigMap, obj, "unused")
+			if err != nil {
+				t.Fatalf("Due() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Due() = %v, want %v", got, tt.want)
+			}
+			if a := actions(rep); !sameActions(a, tt.wantActions) {
+				t.Errorf("recorded %v, want %v", a, tt.wantActions)
+			}
+
+			stored := &corev1.ConfigMap{}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(obj), stored); err != nil {
+				t.Fatal(err)
+			}
+			if _, marked := stored.Annotations[v1.CandidateSinceKey]; marked != tt.wantMarked {
+				t.Errorf("stored mark = %v, want %v", marked, tt.wantMarked)
+			}
+		})
+	}
+}
+
+func TestDueFor(t *testing.T) {
+	longAgo := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name        string
+		cleaner     v1.ResourceCleaner
+		annotations map[string]string
+		want        bool
+		wantPlanned string
+		wantMarked  bool
+	}{
+		{
+			name:        "dry run plans the object acted on",
+			cleaner:     cleaner("", 0),
+			wantPlanned: "owner",
+		},
+		{
+			name:       "mark goes on the object found unused",
+			cleaner:    cleaner(v1.CleanUp, 24*time.Hour),
+			wantMarked: true,
+		},
+		{
+			name:        "grace period of the object found unused",
+			cleaner:     cleaner(v1.CleanUp, 24*time.Hour),
+			annotations: map[string]string{v1.CandidateSinceKey: longAgo},
+			want:        true,
+			wantMarked:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			marked := configMap(tt.annotations)
+			obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "owner", Namespace: "dev"}}
+			c := fake.NewClientBuilder().WithObjects(marked, obj).Build()
+			rep := NewReport()
+
+			got, err := DueFor(ctx, c, tt.cleaner, rep, v1.Pod, marked, v1.ConfigMap, obj, "unused")
+			if err != nil {
+				t.Fatalf("DueFor() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DueFor() = %v, want %v", got, tt.want)
+			}
+			var planned string
+			for _, o := range rep.plan {
+				planned = o.Name
+			}
+			if planned != tt.wantPlanned {
+				t.Errorf("planned %q, want %q", planned, tt.wantPlanned)
+			}
+
+			for _, o := range []client.Object{marked, obj} {
+				stored := &corev1.ConfigMap{}
+				if err := c.Get(ctx, client.ObjectKeyFromObject(o), stored); err != nil {
+					t.Fatal(err)
+				}
+				_, isMarked := stored.Annotations[v1.CandidateSinceKey]
+				if want := tt.wantMarked && o == marked; isMarked != want {
+					t.Errorf("%s marked = %v, want %v", o.GetName(), isMarked, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFlag(t *testing.T) {
+	tests := []struct {
+		name        string
+		cleaner     v1.ResourceCleaner
+		annotations map[string]string
+		wantActions []v1.SweepAction
+	}{
+		{
+			name:        "cleanup within grace period",
+			cleaner:     cleaner(v1.CleanUp, 24*time.Hour),
+			wantActions: []v1.SweepAction{v1.Flagged},
+		},
+		{
+			name:        "dry run",
+			cleaner:     cleaner("", 0),
+			wantActions: []v1.SweepAction{v1.Flagged},
+		},
+		{
+			name:        "protected",
+			cleaner:     cleaner(v1.CleanUp, 0),
+			annotations: map[string]string{v1.ProtectKey: "true"},
+			wantActions: []v1.SweepAction{v1.Protected},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			obj := configMap(tt.annotations)
+			c := fake.NewClientBuilder().WithObjects(obj).Build()
+			rep := NewReport()
+
+			if err := Flag(ctx, c, tt.cleaner, rep, v1.ConfigMap, obj, "unused"); err != nil {
+				t.Fatalf("Flag() error = %v", err)
+			}
+			if a := actions(rep); !sameActions(a, tt.wantActions) {
+				t.Errorf("recorded %v, want %v", a, tt.wantActions)
+			}
+			if len(rep.plan) != 0 {
+				t.Errorf("plan = %v, want none", rep.plan)
+			}
+
+			stored := &corev1.ConfigMap{}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(obj), stored); err != nil {
+				t.Fatal(err)
+			}
+			if _, marked := stored.Annotations[v1.CandidateSinceKey]; marked {
+				t.Errorf("Flag() marked the object")
+			}
+		})
+	}
+}
+
+func TestMarkers(t *testing.T) {
+	recently := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	ruleKey := v1.CandidateSinceKey + "-rule"
+
+	tests := []struct {
+		name        string
+		marker      string
+		recover     bool
+		annotations map[string]string
+		wantKeys    map[string]bool
+	}{
+		{
+			name:     "handler mark",
+			wantKeys: map[string]bool{v1.CandidateSinceKey: true, ruleKey: false},
+		},
+		{
+			name:     "marks of its own",
+			marker:   "rule",
+			wantKeys: map[string]bool{v1.CandidateSinceKey: false, ruleKey: true},
+		},
+		{
+			name:        "recover leaves the handler mark",
+			marker:      "rule",
+			recover:     true,
+			annotations: map[string]string{v1.CandidateSinceKey: recently, ruleKey: recently},
+			wantKeys:    map[string]bool{v1.CandidateSinceKey: true, ruleKey: false},
+		},
+		{
+			name:        "handler recover leaves other marks",
+			recover:     true,
+			annotations: map[string]string{v1.CandidateSinceKey: recently, ruleKey: recently},
+			wantKeys:    map[string]bool{v1.CandidateSinceKey: false, ruleKey: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.marker != "" {
+				ctx = WithMarker(ctx, tt.marker)
+			}
+			obj := configMap(tt.annotations)
+			c := fake.NewClientBuilder().WithObjects(obj).Build()
+			cleaner := cleaner(v1.CleanUp, 24*time.Hour)
+
+			var err error
+			if tt.recover {
+				err = Recover(ctx, c, cleaner, NewReport(), v1.ConfigMap, obj)
+			} else {
+				_, err = Due(ctx, c, cleaner, NewReport(), v1.ConfigMap, obj, "unused")
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stored := &corev1.ConfigMap{}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(obj), stored); err != nil {
+				t.Fatal(err)
+			}
+			for key, want := range tt.wantKeys {
+				if _, got := stored.Annotations[key]; got != want {
+					t.Errorf("annotation %s set = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
@@ -6,32 +6,42 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	v1 "kubefit.com/kubeswipe/api/v1"
+	"kubefit.com/kubeswipe/pkg/utils/autoscalers"
+	"kubefit.com/kubeswipe/pkg/utils/configmaps"
+	"kubefit.com/kubeswipe/pkg/utils/cronjobs"
+	"kubefit.com/kubeswipe/pkg/utils/disruptionbudgets"
 	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/ingresses"
+	"kubefit.com/kubeswipe/pkg/utils/jobs"
 	"kubefit.com/kubeswipe/pkg/utils/namespaces"
+	"kubefit.com/kubeswipe/pkg/utils/networkpolicies"
+	"kubefit.com/kubeswipe/pkg/utils/orphans"
 	"kubefit.com/kubeswipe/pkg/utils/pods"
+	"kubefit.com/kubeswipe/pkg/utils/quotas"
+	"kubefit.com/kubeswipe/pkg/utils/rbac"
+	"kubefit.com/kubeswipe/pkg/utils/replicasets"
+	"kubefit.com/kubeswipe/pkg/utils/rules"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/secrets"
+	"kubefit.com/kubeswipe/pkg/utils/serviceaccounts"
 	"kubefit.com/kubeswipe/pkg/utils/services"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"kubefit.com/kubeswipe/pkg/utils/volumes"
+	"kubefit.com/kubeswipe/pkg/utils/workloads"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func HandleAllUnusedResources(ctx context.Context, client client.Client, cleaner v1.ResourceCleaner) error {
+func HandleAllUnusedResources(ctx context.Context, client client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
 	logger := log.FromContext(ctx)
 	if len(cleaner.Spec.Resources.Include) == 0 && len(cleaner.Spec.Resources.Exclude) == 0 {
-		err := CleanAllResources(ctx, client, cleaner)
+		err := CleanAllResources(ctx, client, cleaner, rep)
 		if err != nil {
 			logger.Error(err, "cleaning all resources")
 			return err
 		}
 
 	} else {
-		resourcesMap := make(map[string]bool)
-		for _, includedResource := range cleaner.Spec.Resources.Include {
-			resourcesMap[includedResource.Name] = true
-		}
-		for _, excludedResource := range cleaner.Spec.Resources.Exclude {
-			resourcesMap[excludedResource.Name] = false
-		}
-
-		err := HandleUnusedResourcesInSteps(ctx, client, cleaner, resourcesMap)
+		err := HandleUnusedResourcesInSteps(ctx, client, cleaner, rep)
 		if err != nil {
 			logger.Error(err, "handling unused resources")
 			return err
@@ -42,60 +52,289 @@ func HandleAllUnusedResources(ctx context.Context, client client.Client, cleaner
 	return nil
 }
 
-func HandleUnusedResourcesInSteps(ctx context.Context, client client.Client, cleaner v1.ResourceCleaner, resourceMap map[string]bool) error {
+// HandleUnusedResourcesInSteps runs the handler of every kind the include and
+// exclude entries leave in scope. Each handler narrows further down to the
+// namespaces and objects of its own scope.
+func HandleUnusedResourcesInSteps(ctx context.Context, client client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
 	logger := log.FromContext(ctx)
-	for resourceName, included := range resourceMap {
-		if resourceName == "Namespace" && included {
-			err := namespaces.ForceDeleteTerminatingNamespaces(ctx, client,cleaner)
-			if err != nil {
-				logger.Error(err, "force deleting namespaces")
-				return err
-			}
-		}
-		if resourceName == "Service" && included {
-			err := services.HandleAllUnusedServices(ctx, client, cleaner)
-			if err != nil {
-				logger.Error(err, "handling services")
-				return err
-			}
-		}
-		if resourceName == "Pod" && included {
-			err := pods.DeleteAllPendingAndFailedPods(ctx, client, cleaner)
-			if err != nil {
-				logger.Error(err, "handling services")
-				return err
-			}
+	var errors []error
+	if scope.For(cleaner, v1.Namespace).Enabled() {
+		err := namespaces.ForceDeleteTerminatingNamespaces(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "force deleting namespaces")
+			errors = append(errors, err)
+		}
+		err = namespaces.DeleteEmptyNamespaces(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "deleting empty namespaces")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.Service).Enabled() {
+		err := services.HandleAllUnusedServices(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling services")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.Ingress).Enabled() {
+		err := ingresses.HandleAllDeadIngresses(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling ingresses")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.Pod).Enabled() {
+		err := pods.DeleteAllPendingAndFailedPods(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling pods")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.Job).Enabled() {
+		err := jobs.HandleAllFinishedJobs(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling jobs")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.CronJob).Enabled() {
+		err := cronjobs.HandleAllIdleCronJobs(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling cron jobs")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.Deployment).Enabled() {
+		err := workloads.HandleAllScaledDownDeployments(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling deployments")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.StatefulSet).Enabled() {
+		err := workloads.HandleAllScaledDownStatefulSets(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling stateful sets")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.ReplicaSet).Enabled() {
+		err := replicasets.HandleAllOldReplicaSets(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling replica sets")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.HorizontalPodAutoscaler).Enabled() {
+		err := autoscalers.HandleAllOrphanedAutoscalers(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling horizontal pod autoscalers")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.PodDisruptionBudget).Enabled() {
+		err := disruptionbudgets.HandleAllUnmatchedDisruptionBudgets(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling pod disruption budgets")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.NetworkPolicy).Enabled() {
+		err := networkpolicies.HandleAllUnmatchedNetworkPolicies(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling network policies")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.ResourceQuota).Enabled() {
+		err := quotas.HandleAllUnusedResourceQuotas(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling resource quotas")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.LimitRange).Enabled() {
+		err := quotas.HandleAllUnusedLimitRanges(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling limit ranges")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.ConfigMap).Enabled() {
+		err := configmaps.HandleAllUnusedConfigMaps(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling config maps")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.Secret).Enabled() {
+		err := secrets.HandleAllUnusedSecrets(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling secrets")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.ServiceAccount).Enabled() {
+		err := serviceaccounts.HandleAllUnusedServiceAccounts(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling service accounts")
+			errors = append(errors, err)
+		}
+	}
+	// the RBAC kinds share one index, rbac.HandleAll checks their scopes
+	if err := rbac.HandleAll(ctx, client, cleaner, rep); err != nil {
+		logger.Error(err, "handling rbac")
+		errors = append(errors, err)
+	}
+	// volumes hold data and are only swept when listed explicitly, which is
+	// why CleanAllResources leaves them out
+	if scope.For(cleaner, v1.PersistantVolumeClaim).Listed() {
+		err := volumes.HandleAllUnusedPersistentVolumeClaims(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling persistent volume claims")
+			errors = append(errors, err)
+		}
+	}
+	if scope.For(cleaner, v1.PersistantVolume).Listed() {
+		err := volumes.HandleAllReleasedPersistentVolumes(ctx, client, cleaner, rep)
+		if err != nil {
+			logger.Error(err, "handling persistent volumes")
+			errors = append(errors, err)
 		}
 	}
+	// rules carry their own scope
+	if err := rules.HandleAllRules(ctx, client, cleaner, rep); err != nil {
+		logger.Error(err, "handling rules")
+		errors = append(errors, err)
+	}
+	if err := orphans.HandleAllOrphans(ctx, client, cleaner, rep); err != nil {
+		logger.Error(err, "handling orphans")
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
 	return nil
 }
 
-func CleanAllResources(ctx context.Context, client client.Client, cleaner v1.ResourceCleaner) error {
+func CleanAllResources(ctx context.Context, client client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
 	var errors []error
 
-	err := namespaces.ForceDeleteTerminatingNamespaces(ctx, client, cleaner)
+	err := namespaces.ForceDeleteTerminatingNamespaces(ctx, client, cleaner, rep)
 	if err != nil {
 		errors = append(errors, err)
 	}
-	err = services.HandleAllUnusedServices(ctx, client, cleaner)
+
+	err = namespaces.DeleteEmptyNamespaces(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+	err = services.HandleAllUnusedServices(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = ingresses.HandleAllDeadIngresses(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = pods.DeleteAllPendingAndFailedPods(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = jobs.HandleAllFinishedJobs(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = cronjobs.HandleAllIdleCronJobs(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = workloads.HandleAllScaledDownDeployments(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = workloads.HandleAllScaledDownStatefulSets(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = replicasets.HandleAllOldReplicaSets(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = autoscalers.HandleAllOrphanedAutoscalers(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = disruptionbudgets.HandleAllUnmatchedDisruptionBudgets(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = networkpolicies.HandleAllUnmatchedNetworkPolicies(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = quotas.HandleAllUnusedResourceQuotas(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = quotas.HandleAllUnusedLimitRanges(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = configmaps.HandleAllUnusedConfigMaps(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = secrets.HandleAllUnusedSecrets(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = serviceaccounts.HandleAllUnusedServiceAccounts(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = rbac.HandleAll(ctx, client, cleaner, rep)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = rules.HandleAllRules(ctx, client, cleaner, rep)
 	if err != nil {
 		errors = append(errors, err)
 	}
 
-	err = pods.DeleteAllPendingAndFailedPods(ctx, client, cleaner)
+	err = orphans.HandleAllOrphans(ctx, client, cleaner, rep)
 	if err != nil {
 		errors = append(errors, err)
 	}
 
 	if cleaner.Spec.SwipePolicy == v1.Moderate {
-		err = pods.DeleteAllUnusedPods(ctx, client,cleaner)
+		err = pods.DeleteAllUnusedPods(ctx, client, cleaner, rep)
 		if err != nil {
 			errors = append(errors, err)
 		}
 	}
 
 	if len(errors) > 0 {
-		errorsUtil.AggregateErrors(errors)
+		return errorsUtil.AggregateErrors(errors)
 	}
 
 	return nil
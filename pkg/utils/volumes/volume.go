@@ -0,0 +1,242 @@
+package volumes
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/podspecs"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultUnusedFor = 7 * 24 * time.Hour
+	// defaultClassAnnotation marks the StorageClass claims without a class get.
+	defaultClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+)
+
+// Cleaner returns the cleaner volumes are swept with: backups are always
+// taken and objects have to stay unused for the volume policy's UnusedFor
+// first.
+func Cleaner(cleaner v1.ResourceCleaner) v1.ResourceCleaner {
+	unusedFor := defaultUnusedFor
+	if cleaner.Spec.Volumes != nil && cleaner.Spec.Volumes.UnusedFor != nil {
+		unusedFor = cleaner.Spec.Volumes.UnusedFor.Duration
+	}
+	cleaner.Spec.Resources.Backup = true
+	return sweep.WithMinGrace(cleaner, unusedFor)
+}
+
+func HandleAllUnusedPersistentVolumeClaims(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	s := scope.For(cleaner, v1.PersistantVolumeClaim)
+	if !s.Listed() {
+		return s.Err()
+	}
+	namespaces, err := s.Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	classes, defaultClass, err := storageClasses(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	cleaner = Cleaner(cleaner)
+	for _, ns := range namespaces {
+		err := handleUnusedClaimsInNamespace(ctx, c, ns, cleaner, rep, classes, defaultClass)
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleUnusedClaimsInNamespace(ctx context.Context, c client.Client, ns corev1.Namespace, cleaner v1.ResourceCleaner, rep *sweep.Report, classes map[string]bool, defaultClass bool) error {
+	var errors []error
+	s := scope.For(cleaner, v1.PersistantVolumeClaim)
+
+	mounted, err := mountedClaims(ctx, c, ns.Name)
+	if err != nil {
+		return err
+	}
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := c.List(ctx, statefulSets, &client.ListOptions{Namespace: ns.Name}); err != nil {
+		return err
+	}
+
+	claims := &corev1.PersistentVolumeClaimList{}
+	if err := c.List(ctx, claims, s.ListOptions(ns.Name)); err != nil {
+		return err
+	}
+	for _, pvc := range claims.Items {
+		pvc := pvc
+		if !s.Contains(&ns, &pvc) {
+			continue
+		}
+
+		reason := ""
+		switch {
+		// ephemeral volume claims belong to their pod
+		case len(pvc.OwnerReferences) > 0:
+		case pvc.Status.Phase == corev1.ClaimPending:
+			reason = pendingReason(pvc, classes, defaultClass)
+		case !mounted[pvc.Name] && !claimedByStatefulSet(pvc.Name, statefulSets.Items):
+			reason = "claim is not mounted by any pod or pod template"
+		}
+
+		if reason == "" {
+			if err := sweep.Recover(ctx, c, cleaner, rep, v1.PersistantVolumeClaim, &pvc); err != nil {
+				errors = append(errors, err)
+			}
+			continue
+		}
+		if err := sweep.Delete(ctx, c, cleaner, rep, v1.PersistantVolumeClaim, &pvc, reason); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func HandleAllReleasedPersistentVolumes(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	var errors []error
+	s := scope.For(cleaner, v1.PersistantVolume)
+	if !s.Listed() {
+		return s.Err()
+	}
+	cleaner = Cleaner(cleaner)
+
+	volumes := &corev1.PersistentVolumeList{}
+	if err := c.List(ctx, volumes, s.ListOptions("")); err != nil {
+		return err
+	}
+	for _, pv := range volumes.Items {
+		pv := pv
+		ns, err := claimNamespace(ctx, c, pv)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		if !s.Contains(ns, &pv) {
+			continue
+		}
+
+		if pv.Status.Phase != corev1.VolumeReleased && pv.Status.Phase != corev1.VolumeFailed {
+			if err := sweep.Recover(ctx, c, cleaner, rep, v1.PersistantVolume, &pv); err != nil {
+				errors = append(errors, err)
+			}
+			continue
+		}
+
+		reason := "volume is " + string(pv.Status.Phase) + " with reclaim policy " + string(pv.Spec.PersistentVolumeReclaimPolicy)
+		// deleting a retained volume only removes the object, the disk stays
+		// allocated and has to be released by hand
+		if pv.Spec.PersistentVolumeReclaimPolicy == corev1.PersistentVolumeReclaimRetain {
+			err = sweep.Flag(ctx, c, cleaner, rep, v1.PersistantVolume, &pv, reason+", its disk has to be released by hand")
+		} else {
+			err = sweep.Delete(ctx, c, cleaner, rep, v1.PersistantVolume, &pv, reason)
+		}
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+// claimNamespace returns the namespace of the claim pv is or was bound to, so
+// volumes are scoped like their claims. Volumes that were never bound have
+// none.
+func claimNamespace(ctx context.Context, c client.Client, pv corev1.PersistentVolume) (*corev1.Namespace, error) {
+	if pv.Spec.ClaimRef == nil || pv.Spec.ClaimRef.Namespace == "" {
+		return nil, nil
+	}
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKey{Name: pv.Spec.ClaimRef.Namespace}, ns); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		// the namespace is gone, only its name can still match
+		ns.Name = pv.Spec.ClaimRef.Namespace
+	}
+	return ns, nil
+}
+
+func mountedClaims(ctx context.Context, c client.Client, namespace string) (map[string]bool, error) {
+	specs, err := podspecs.InNamespace(ctx, c, namespace)
+	if err != nil {
+		return nil, err
+	}
+	mounted := make(map[string]bool)
+	for _, spec := range specs {
+		for _, name := range podspecs.PersistentVolumeClaims(spec) {
+			mounted[name] = true
+		}
+	}
+	return mounted, nil
+}
+
+// claimedByStatefulSet reports whether name was created from the volume claim
+// template of a StatefulSet that still exists, which keeps its claims while
+// scaled down.
+func claimedByStatefulSet(name string, statefulSets []appsv1.StatefulSet) bool {
+	for _, sts := range statefulSets {
+		for _, template := range sts.Spec.VolumeClaimTemplates {
+			if strings.HasPrefix(name, template.Name+"-"+sts.Name+"-") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func storageClasses(ctx context.Context, c client.Client) (map[string]bool, bool, error) {
+	classList := &storagev1.StorageClassList{}
+	if err := c.List(ctx, classList); err != nil {
+		return nil, false, err
+	}
+	classes := make(map[string]bool)
+	defaultClass := false
+	for _, class := range classList.Items {
+		classes[class.Name] = true
+		if class.Annotations[defaultClassAnnotation] == "true" {
+			defaultClass = true
+		}
+	}
+	return classes, defaultClass, nil
+}
+
+// pendingReason returns why a Pending claim can never bind, or "" if it may.
+func pendingReason(pvc corev1.PersistentVolumeClaim, classes map[string]bool, defaultClass bool) string {
+	if pvc.Spec.VolumeName != "" {
+		return ""
+	}
+	class := pvc.Spec.StorageClassName
+	switch {
+	case class == nil && !defaultClass:
+		return "claim is pending without a storage class and there is no default storage class"
+	case class != nil && *class != "" && !classes[*class]:
+		return "claim is pending on storage class " + *class + " which does not exist"
+	}
+	return ""
+}
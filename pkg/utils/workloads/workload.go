@@ -0,0 +1,329 @@
+package workloads
+
+import (
+	"context"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	v1 "kubefit.com/kubeswipe/api/v1"
+	errorsUtil "kubefit.com/kubeswipe/pkg/utils/errors"
+	"kubefit.com/kubeswipe/pkg/utils/scope"
+	"kubefit.com/kubeswipe/pkg/utils/sweep"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const defaultScaledDownFor = 7 * 24 * time.Hour
+
+// workloadCleaner returns the cleaner workloads are swept with: backups are
+// always taken, since a workload goes together with its ReplicaSets,
+// Services and HPAs, and workloads have to stay scaled down for the
+// workload policy's ScaledDownFor first.
+func workloadCleaner(cleaner v1.ResourceCleaner) v1.ResourceCleaner {
+	scaledDownFor := defaultScaledDownFor
+	if cleaner.Spec.Workloads != nil && cleaner.Spec.Workloads.ScaledDownFor != nil {
+		scaledDownFor = cleaner.Spec.Workloads.ScaledDownFor.Duration
+	}
+	cleaner.Spec.Resources.Backup = true
+	return sweep.WithMinGrace(cleaner, scaledDownFor)
+}
+
+// workload is what the handlers need to know of a Deployment or StatefulSet.
+type workload struct {
+	kind     v1.ResourceNames
+	obj      client.Object
+	replicas *int32
+	template labels.Set
+	// service is the governing Service of a StatefulSet.
+	service string
+}
+
+// namespaceState holds the objects of a namespace that workloads leave
+// behind, and the pod labels that keep Services in use.
+type namespaceState struct {
+	replicaSets []appsv1.ReplicaSet
+	services    []corev1.Service
+	hpas        []autoscalingv2.HorizontalPodAutoscaler
+	// podLabels are the labels of every pod and of every workload's pod
+	// template in the namespace, by the UID of the workload they come from.
+	podLabels map[types.UID][]labels.Set
+}
+
+func HandleAllScaledDownDeployments(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	return handleAllScaledDown(ctx, c, cleaner, rep, v1.Deployment)
+}
+
+func HandleAllScaledDownStatefulSets(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report) error {
+	return handleAllScaledDown(ctx, c, cleaner, rep, v1.StatefulSet)
+}
+
+func handleAllScaledDown(ctx context.Context, c client.Client, cleaner v1.ResourceCleaner, rep *sweep.Report, kind v1.ResourceNames) error {
+	var errors []error
+	namespaces, err := scope.For(cleaner, kind).Namespaces(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	cleaner = workloadCleaner(cleaner)
+	for _, ns := range namespaces {
+		if scope.SystemNamespace(ns.Name) {
+			continue
+		}
+		if err := handleScaledDownInNamespace(ctx, c, ns, cleaner, rep, kind); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+func handleScaledDownInNamespace(ctx context.Context, c client.Client, ns corev1.Namespace, cleaner v1.ResourceCleaner, rep *sweep.Report, kind v1.ResourceNames) error {
+	var errors []error
+	s := scope.For(cleaner, kind)
+
+	workloads, err := listWorkloads(ctx, c, kind, s.ListOptions(ns.Name))
+	if err != nil {
+		return err
+	}
+	state, err := loadNamespace(ctx, c, ns.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range workloads {
+		if !s.Contains(&ns, w.obj) || len(w.obj.GetOwnerReferences()) > 0 {
+			continue
+		}
+
+		if w.replicas == nil || *w.replicas > 0 {
+			if err := sweep.Recover(ctx, c, cleaner, rep, w.kind, w.obj); err != nil {
+				errors = append(errors, err)
+			}
+			continue
+		}
+
+		reason := string(w.kind) + " is scaled to zero"
+		err := sweep.DeleteWith(ctx, c, cleaner, rep, w.kind, w.obj, reason, inScope(cleaner, &ns, state.leftovers(w)))
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errorsUtil.AggregateErrors(errors)
+	}
+	return nil
+}
+
+// ScaleToZero sets the replicas of obj, a Deployment, StatefulSet or
+// ReplicaSet of the given kind, to zero and records it in rep.
+func ScaleToZero(ctx context.Context, c client.Client, rep *sweep.Report, kind v1.ResourceNames, obj client.Object, reason string) error {
+	patch := client.RawPatch(types.MergePatchType, []byte(`{"spec":{"replicas":0}}`))
+	if err := c.Patch(ctx, obj, patch); err != nil {
+		rep.Record(kind, obj, v1.Failed, "scaling to zero: "+err.Error())
+		return err
+	}
+	rep.Record(kind, obj, v1.Suspended, reason)
+	return nil
+}
+
+func listWorkloads(ctx context.Context, c client.Client, kind v1.ResourceNames, opts *client.ListOptions) ([]workload, error) {
+	var workloads []workload
+	switch kind {
+	case v1.Deployment:
+		list := &appsv1.DeploymentList{}
+		if err := c.List(ctx, list, opts); err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			d := &list.Items[i]
+			workloads = append(workloads, workload{
+				kind:     kind,
+				obj:      d,
+				replicas: d.Spec.Replicas,
+				template: d.Spec.Template.Labels,
+			})
+		}
+	case v1.StatefulSet:
+		list := &appsv1.StatefulSetList{}
+		if err := c.List(ctx, list, opts); err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			sts := &list.Items[i]
+			workloads = append(workloads, workload{
+				kind:     kind,
+				obj:      sts,
+				replicas: sts.Spec.Replicas,
+				template: sts.Spec.Template.Labels,
+				service:  sts.Spec.ServiceName,
+			})
+		}
+	}
+	return workloads, nil
+}
+
+func loadNamespace(ctx context.Context, c client.Client, namespace string) (*namespaceState, error) {
+	opts := &client.ListOptions{Namespace: namespace}
+	state := &namespaceState{podLabels: make(map[types.UID][]labels.Set)}
+
+	replicaSets := &appsv1.ReplicaSetList{}
+	if err := c.List(ctx, replicaSets, opts); err != nil {
+		return nil, err
+	}
+	state.replicaSets = replicaSets.Items
+
+	services := &corev1.ServiceList{}
+	if err := c.List(ctx, services, opts); err != nil {
+		return nil, err
+	}
+	state.services = services.Items
+
+	hpas := &autoscalingv2.HorizontalPodAutoscalerList{}
+	if err := c.List(ctx, hpas, opts); err != nil {
+		return nil, err
+	}
+	state.hpas = hpas.Items
+
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, opts); err != nil {
+		return nil, err
+	}
+	for _, pod := range pods.Items {
+		state.addPodLabels(pod.OwnerReferences, pod.UID, pod.Labels)
+	}
+	for _, rs := range replicaSets.Items {
+		state.addPodLabels(rs.OwnerReferences, rs.UID, rs.Spec.Template.Labels)
+	}
+	deployments := &appsv1.DeploymentList{}
+	if err := c.List(ctx, deployments, opts); err != nil {
+		return nil, err
+	}
+	for _, d := range deployments.Items {
+		state.addPodLabels(nil, d.UID, d.Spec.Template.Labels)
+	}
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := c.List(ctx, statefulSets, opts); err != nil {
+		return nil, err
+	}
+	for _, sts := range statefulSets.Items {
+		state.addPodLabels(nil, sts.UID, sts.Spec.Template.Labels)
+	}
+	daemonSets := &appsv1.DaemonSetList{}
+	if err := c.List(ctx, daemonSets, opts); err != nil {
+		return nil, err
+	}
+	for _, ds := range daemonSets.Items {
+		state.addPodLabels(nil, ds.UID, ds.Spec.Template.Labels)
+	}
+
+	return state, nil
+}
+
+// addPodLabels files set under the UID of the object's controller, or under
+// uid for objects without one. Pods of a ReplicaSet end up under the
+// ReplicaSet, which leftovers resolves to its Deployment.
+func (state *namespaceState) addPodLabels(owners []metav1.OwnerReference, uid types.UID, set labels.Set) {
+	for _, owner := range owners {
+		if owner.Controller != nil && *owner.Controller {
+			uid = owner.UID
+		}
+	}
+	state.podLabels[uid] = append(state.podLabels[uid], set)
+}
+
+// leftovers returns what w leaves behind once deleted: its ReplicaSets, the
+// HPAs scaling it, and the Services that select only its pods.
+func (state *namespaceState) leftovers(w workload) []sweep.Dependent {
+	var dependents []sweep.Dependent
+	name := w.obj.GetName()
+	own := map[types.UID]bool{w.obj.GetUID(): true}
+
+	for i := range state.replicaSets {
+		rs := &state.replicaSets[i]
+		if owner := metav1.GetControllerOf(rs); owner != nil && owner.UID == w.obj.GetUID() {
+			own[rs.UID] = true
+			dependents = append(dependents, sweep.Dependent{
+				Kind:   v1.ReplicaSet,
+				Object: rs,
+				Reason: "replica set of scaled down " + string(w.kind) + " " + name,
+			})
+		}
+	}
+
+	for i := range state.hpas {
+		hpa := &state.hpas[i]
+		if hpa.Spec.ScaleTargetRef.Kind == string(w.kind) && hpa.Spec.ScaleTargetRef.Name == name {
+			dependents = append(dependents, sweep.Dependent{
+				Kind:   v1.HorizontalPodAutoscaler,
+				Object: hpa,
+				Reason: "autoscaler of scaled down " + string(w.kind) + " " + name,
+			})
+		}
+	}
+
+	for i := range state.services {
+		svc := &state.services[i]
+		governing := svc.Name == w.service
+		if !governing && !selects(svc, w.template) {
+			continue
+		}
+		if len(svc.OwnerReferences) > 0 || state.selectsOthers(svc, own) {
+			continue
+		}
+		dependents = append(dependents, sweep.Dependent{
+			Kind:   v1.Service,
+			Object: svc,
+			Reason: "service of scaled down " + string(w.kind) + " " + name,
+		})
+	}
+	return dependents
+}
+
+// inScope drops the dependents the cleaner's scope of their own kind leaves
+// out. The ReplicaSets of a Deployment are still removed with it by garbage
+// collection.
+func inScope(cleaner v1.ResourceCleaner, ns *corev1.Namespace, dependents []sweep.Dependent) []sweep.Dependent {
+	var out []sweep.Dependent
+	for _, d := range dependents {
+		if scope.For(cleaner, d.Kind).Contains(ns, d.Object) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// selects reports whether svc selects the pods of template.
+func selects(svc *corev1.Service, template labels.Set) bool {
+	if len(svc.Spec.Selector) == 0 {
+		return false
+	}
+	return labels.SelectorFromSet(svc.Spec.Selector).Matches(template)
+}
+
+// selectsOthers reports whether svc selects pods, or pod templates, that do
+// not come from one of the objects in own.
+func (state *namespaceState) selectsOthers(svc *corev1.Service, own map[types.UID]bool) bool {
+	if len(svc.Spec.Selector) == 0 {
+		return false
+	}
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+	for uid, sets := range state.podLabels {
+		if own[uid] {
+			continue
+		}
+		for _, set := range sets {
+			if selector.Matches(set) {
+				return true
+			}
+		}
+	}
+	return false
+}